@@ -5,11 +5,13 @@ import (
 	"math"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"unsafe"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/noise"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 	wgpuext_glfw "github.com/rajveermalviya/go-webgpu/wgpuext/glfw"
 
@@ -38,8 +40,8 @@ func init() {
 }
 
 type Vertex struct {
-	pos      [4]float32
-	texCoord [2]float32
+	pos      glm.Vec4[float32]
+	texCoord glm.Vec2[float32]
 }
 
 var VertexBufferLayout = wgpu.VertexBufferLayout{
@@ -61,8 +63,8 @@ var VertexBufferLayout = wgpu.VertexBufferLayout{
 
 func vertex(pos1, pos2, pos3, tc1, tc2 float32) Vertex {
 	return Vertex{
-		pos:      [4]float32{pos1, pos2, pos3, 1},
-		texCoord: [2]float32{tc1, tc2},
+		pos:      glm.Vec4[float32]{pos1, pos2, pos3, 1},
+		texCoord: glm.Vec2[float32]{tc1, tc2},
 	}
 }
 
@@ -127,6 +129,20 @@ func createTexels() (texels [texelsSize * texelsSize]uint8) {
 	return texels
 }
 
+// createNoiseTexels fills the texture with fBm perlin noise, values
+// are kept below 0x33 where the palette in shader.wgsl saturates.
+func createNoiseTexels(seed uint32) (texels [texelsSize * texelsSize]uint8) {
+	n := noise.New(seed)
+	for id := 0; id < (texelsSize * texelsSize); id++ {
+		x := 8 * float32(id%texelsSize) / texelsSize
+		y := 8 * float32(id/texelsSize) / texelsSize
+		v := noise.FBm2(n.Perlin2, x, y, 5, 2, 0.5)*0.5 + 0.5
+		texels[id] = uint8(v * 0x33)
+	}
+
+	return texels
+}
+
 func generateMatrix(aspectRatio float32) glm.Mat4[float32] {
 	projection := glm.PerspectiveRH(math.Pi/4, aspectRatio, 1, 10)
 	view := glm.LookAtRH(
@@ -141,12 +157,23 @@ func generateMatrix(aspectRatio float32) glm.Mat4[float32] {
 //go:embed shader.wgsl
 var shader string
 
+// Uniforms matches the uniform struct of shader.wgsl.
+type Uniforms struct {
+	transform glm.Mat4[float32]
+	noiseSeed uint32
+	_         [3]uint32
+}
+
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface    *wgpu.Surface
 	swapChain  *wgpu.SwapChain
 	device     *wgpu.Device
 	queue      *wgpu.Queue
 	config     *wgpu.SwapChainDescriptor
+	clearColor wgpu.Color
 	vertexBuf  *wgpu.Buffer
 	indexBuf   *wgpu.Buffer
 	uniformBuf *wgpu.Buffer
@@ -200,6 +227,8 @@ func InitState(window *glfw.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	s.vertexBuf, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(vertexData[:]),
@@ -218,7 +247,18 @@ func InitState(window *glfw.Window) (s *State, err error) {
 		return s, err
 	}
 
+	// with CUBE_NOISE_GPU set the noise is also evaluated by the
+	// fragment shader and compared to the texture
 	texels := createTexels()
+	uniforms := Uniforms{}
+	fragmentEntryPoint := "fs_main"
+	if seed, err := strconv.ParseUint(os.Getenv("CUBE_NOISE_SEED"), 10, 32); err == nil {
+		texels = createNoiseTexels(uint32(seed))
+		uniforms.noiseSeed = uint32(seed)
+		if os.Getenv("CUBE_NOISE_GPU") != "" {
+			fragmentEntryPoint = "fs_noise"
+		}
+	}
 	textureExtent := wgpu.Extent3D{
 		Width:              texelsSize,
 		Height:             texelsSize,
@@ -254,10 +294,10 @@ func InitState(window *glfw.Window) (s *State, err error) {
 		&textureExtent,
 	)
 
-	mxTotal := generateMatrix(float32(s.config.Width) / float32(s.config.Height))
+	uniforms.transform = generateMatrix(float32(s.config.Width) / float32(s.config.Height))
 	s.uniformBuf, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Uniform Buffer",
-		Contents: wgpu.ToBytes(mxTotal[:]),
+		Contents: wgpu.ToBytes([]Uniforms{uniforms}),
 		Usage:    wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
 	})
 	if err != nil {
@@ -266,7 +306,7 @@ func InitState(window *glfw.Window) (s *State, err error) {
 
 	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
 		Label:          "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: shader},
+		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: noise.WGSL + shader},
 	})
 	if err != nil {
 		return s, err
@@ -281,7 +321,7 @@ func InitState(window *glfw.Window) (s *State, err error) {
 		},
 		Fragment: &wgpu.FragmentState{
 			Module:     shader,
-			EntryPoint: "fs_main",
+			EntryPoint: fragmentEntryPoint,
 			Targets: []wgpu.ColorTargetState{
 				{
 					Format:    s.config.Format,
@@ -369,7 +409,7 @@ func (s *State) Render() error {
 				View:       nextTexture,
 				LoadOp:     wgpu.LoadOp_Clear,
 				StoreOp:    wgpu.StoreOp_Store,
-				ClearValue: wgpu.Color{R: 0.1, G: 0.2, B: 0.3, A: 1.0},
+				ClearValue: s.clearColor,
 			},
 		},
 	})
@@ -3,9 +3,14 @@ struct VertexOutput {
     @builtin(position) position: vec4<f32>,
 };
 
+struct Uniforms {
+    transform: mat4x4<f32>,
+    noise_seed: u32,
+};
+
 @group(0)
 @binding(0)
-var<uniform> transform: mat4x4<f32>;
+var<uniform> uniforms: Uniforms;
 
 @vertex
 fn vs_main(
@@ -14,7 +19,7 @@ fn vs_main(
 ) -> VertexOutput {
     var result: VertexOutput;
     result.tex_coord = tex_coord;
-    result.position = transform * position;
+    result.position = uniforms.transform * position;
     return result;
 }
 
@@ -22,11 +27,33 @@ fn vs_main(
 @binding(1)
 var r_color: texture_2d<u32>;
 
+fn palette(texel: u32) -> vec4<f32> {
+    let v = f32(texel) / 255.0;
+    return vec4<f32>(1.0 - (v * 5.0), 1.0 - (v * 15.0), 1.0 - (v * 50.0), 1.0);
+}
+
 @fragment
 fn fs_main(vertex: VertexOutput) -> @location(0) vec4<f32> {
     let tex = textureLoad(r_color, vec2<i32>(vertex.tex_coord * 256.0), 0);
-    let v = f32(tex.x) / 255.0;
-    return vec4<f32>(1.0 - (v * 5.0), 1.0 - (v * 15.0), 1.0 - (v * 50.0), 1.0);
+    return palette(tex.x);
+}
+
+// fs_noise evaluates the noise of createNoiseTexels in main.go on the
+// GPU, texels where it differs from the CPU texture by more than a
+// rounding step are drawn magenta.
+@fragment
+fn fs_noise(vertex: VertexOutput) -> @location(0) vec4<f32> {
+    let texel = vec2<i32>(vertex.tex_coord * 256.0);
+    let cpu = textureLoad(r_color, texel, 0).x;
+
+    let p = 8.0 * vec2<f32>(texel) / 256.0;
+    let v = noise_fbm_perlin2(p, uniforms.noise_seed, 5, 2.0, 0.5) * 0.5 + 0.5;
+    let gpu = u32(v * 51.0);
+
+    if max(cpu, gpu) - min(cpu, gpu) > 1u {
+        return vec4<f32>(1.0, 0.0, 1.0, 1.0);
+    }
+    return palette(gpu);
 }
 
 @fragment
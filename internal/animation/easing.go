@@ -0,0 +1,104 @@
+package animation
+
+import "math"
+
+// Easing remaps a normalized time t in [0, 1], easings
+// must return 0 for t == 0 and 1 for t == 1.
+type Easing[T float32 | float64] func(t T) T
+
+func EaseLinear[T float32 | float64](t T) T { return t }
+
+func EaseInQuad[T float32 | float64](t T) T { return t * t }
+
+func EaseOutQuad[T float32 | float64](t T) T { return t * (2 - t) }
+
+func EaseInOutQuad[T float32 | float64](t T) T {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+func EaseInCubic[T float32 | float64](t T) T { return t * t * t }
+
+func EaseOutCubic[T float32 | float64](t T) T {
+	t--
+	return t*t*t + 1
+}
+
+func EaseInOutCubic[T float32 | float64](t T) T {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return 0.5*t*t*t + 1
+}
+
+func EaseInSine[T float32 | float64](t T) T {
+	return T(1 - math.Cos(float64(t)*math.Pi/2))
+}
+
+func EaseOutSine[T float32 | float64](t T) T {
+	return T(math.Sin(float64(t) * math.Pi / 2))
+}
+
+func EaseInOutSine[T float32 | float64](t T) T {
+	return T(-(math.Cos(math.Pi*float64(t)) - 1) / 2)
+}
+
+func EaseInExpo[T float32 | float64](t T) T {
+	if t == 0 {
+		return 0
+	}
+	return T(math.Pow(2, 10*float64(t)-10))
+}
+
+func EaseOutExpo[T float32 | float64](t T) T {
+	if t == 1 {
+		return 1
+	}
+	return T(1 - math.Pow(2, -10*float64(t)))
+}
+
+func EaseInBack[T float32 | float64](t T) T {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	return c3*t*t*t - c1*t*t
+}
+
+func EaseOutBack[T float32 | float64](t T) T {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	t--
+	return 1 + c3*t*t*t + c1*t*t
+}
+
+func EaseOutElastic[T float32 | float64](t T) T {
+	if t == 0 || t == 1 {
+		return t
+	}
+	const c4 = 2 * math.Pi / 3
+	return T(math.Pow(2, -10*float64(t))*math.Sin((float64(t)*10-0.75)*c4) + 1)
+}
+
+func EaseOutBounce[T float32 | float64](t T) T {
+	const n1 = 7.5625
+	const d1 = 2.75
+	switch {
+	case t < 1/d1:
+		return n1 * t * t
+	case t < 2/d1:
+		t -= 1.5 / d1
+		return n1*t*t + 0.75
+	case t < 2.5/d1:
+		t -= 2.25 / d1
+		return n1*t*t + 0.9375
+	default:
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	}
+}
+
+func EaseInBounce[T float32 | float64](t T) T {
+	return 1 - EaseOutBounce(1-t)
+}
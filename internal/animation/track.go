@@ -0,0 +1,197 @@
+package animation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+type Interpolation uint8
+
+const (
+	// InterpolationStep holds the value of the previous keyframe.
+	InterpolationStep Interpolation = iota
+	// InterpolationLinear lerps between keyframes, quaternions are slerped.
+	InterpolationLinear
+	// InterpolationCubic uses a cubic Hermite spline with the
+	// keyframes' OutTangent and InTangent, tangents are per unit time.
+	InterpolationCubic
+)
+
+type Mode uint8
+
+const (
+	// ModeClamp holds the first and last values outside the track.
+	ModeClamp Mode = iota
+	ModeLoop
+	ModePingPong
+)
+
+type Keyframe[T float32 | float64, V any] struct {
+	Time       T
+	Value      V
+	InTangent  V
+	OutTangent V
+}
+
+type ops[T float32 | float64, V any] struct {
+	lerp    func(a, b V, t T) V
+	hermite func(p0, m0, p1, m1 V, dt, t T) V
+}
+
+// Track is a keyframed value sampled by time, keyframes must
+// be sorted by Time. Tracks must be created with the New*Track
+// functions which know how to interpolate their value type, a
+// zero Track or a Track literal always samples the zero value.
+type Track[T float32 | float64, V any] struct {
+	Keyframes     []Keyframe[T, V]
+	Interpolation Interpolation
+	Mode          Mode
+	// Easing optionally remaps time across the whole track.
+	Easing Easing[T]
+
+	ops ops[T, V]
+}
+
+func NewScalarTrack[T float32 | float64](keyframes []Keyframe[T, T], interpolation Interpolation) *Track[T, T] {
+	return &Track[T, T]{
+		Keyframes:     keyframes,
+		Interpolation: interpolation,
+		ops: ops[T, T]{
+			lerp: func(a, b T, t T) T { return a + (b-a)*t },
+			hermite: func(p0, m0, p1, m1 T, dt, t T) T {
+				h00, h10, h01, h11 := hermiteBasis(t)
+				return h00*p0 + h10*dt*m0 + h01*p1 + h11*dt*m1
+			},
+		},
+	}
+}
+
+func NewVec3Track[T float32 | float64](keyframes []Keyframe[T, glm.Vec3[T]], interpolation Interpolation) *Track[T, glm.Vec3[T]] {
+	return &Track[T, glm.Vec3[T]]{
+		Keyframes:     keyframes,
+		Interpolation: interpolation,
+		ops: ops[T, glm.Vec3[T]]{
+			lerp: func(a, b glm.Vec3[T], t T) glm.Vec3[T] { return a.Lerp(b, t) },
+			hermite: func(p0, m0, p1, m1 glm.Vec3[T], dt, t T) glm.Vec3[T] {
+				h00, h10, h01, h11 := hermiteBasis(t)
+				return p0.MulScalar(h00).
+					Add(m0.MulScalar(h10 * dt)).
+					Add(p1.MulScalar(h01)).
+					Add(m1.MulScalar(h11 * dt))
+			},
+		},
+	}
+}
+
+// NewQuaternionTrack creates a rotation track, linear interpolation
+// uses Slerp and cubic interpolation is normalized after evaluation.
+func NewQuaternionTrack[T float32 | float64](keyframes []Keyframe[T, glm.Quaternion[T]], interpolation Interpolation) *Track[T, glm.Quaternion[T]] {
+	return &Track[T, glm.Quaternion[T]]{
+		Keyframes:     keyframes,
+		Interpolation: interpolation,
+		ops: ops[T, glm.Quaternion[T]]{
+			lerp: func(a, b glm.Quaternion[T], t T) glm.Quaternion[T] { return a.Slerp(b, t) },
+			hermite: func(p0, m0, p1, m1 glm.Quaternion[T], dt, t T) glm.Quaternion[T] {
+				h00, h10, h01, h11 := hermiteBasis(t)
+				return p0.MulScalar(h00).
+					Add(m0.MulScalar(h10 * dt)).
+					Add(p1.MulScalar(h01)).
+					Add(m1.MulScalar(h11 * dt)).
+					Normalize()
+			},
+		},
+	}
+}
+
+func hermiteBasis[T float32 | float64](t T) (h00, h10, h01, h11 T) {
+	t2 := t * t
+	t3 := t2 * t
+	return 2*t3 - 3*t2 + 1, t3 - 2*t2 + t, -2*t3 + 3*t2, t3 - t2
+}
+
+func (tr *Track[T, V]) StartTime() T {
+	if len(tr.Keyframes) == 0 {
+		return 0
+	}
+	return tr.Keyframes[0].Time
+}
+
+func (tr *Track[T, V]) EndTime() T {
+	if len(tr.Keyframes) == 0 {
+		return 0
+	}
+	return tr.Keyframes[len(tr.Keyframes)-1].Time
+}
+
+func (tr *Track[T, V]) Duration() T {
+	return tr.EndTime() - tr.StartTime()
+}
+
+// Sample returns the value of the track at time, wrapped
+// according to the track's Mode.
+func (tr *Track[T, V]) Sample(time T) (v V) {
+	n := len(tr.Keyframes)
+	if n == 0 || tr.ops.lerp == nil {
+		return v
+	}
+	if n == 1 {
+		return tr.Keyframes[0].Value
+	}
+
+	time = tr.wrap(time)
+	if tr.Easing != nil {
+		start, duration := tr.StartTime(), tr.Duration()
+		time = start + tr.Easing((time-start)/duration)*duration
+	}
+
+	// index of the first keyframe after time
+	i := sort.Search(n, func(i int) bool { return tr.Keyframes[i].Time > time })
+	switch {
+	case i == 0:
+		return tr.Keyframes[0].Value
+	case i == n:
+		return tr.Keyframes[n-1].Value
+	}
+
+	k0, k1 := tr.Keyframes[i-1], tr.Keyframes[i]
+	dt := k1.Time - k0.Time
+	t := (time - k0.Time) / dt
+
+	switch tr.Interpolation {
+	case InterpolationLinear:
+		return tr.ops.lerp(k0.Value, k1.Value, t)
+	case InterpolationCubic:
+		return tr.ops.hermite(k0.Value, k0.OutTangent, k1.Value, k1.InTangent, dt, t)
+	default:
+		return k0.Value
+	}
+}
+
+func (tr *Track[T, V]) wrap(time T) T {
+	start, duration := tr.StartTime(), tr.Duration()
+	if duration <= 0 {
+		return start
+	}
+
+	local := float64(time - start)
+	switch tr.Mode {
+	case ModeLoop:
+		local = math.Mod(local, float64(duration))
+		if local < 0 {
+			local += float64(duration)
+		}
+	case ModePingPong:
+		local = math.Mod(local, 2*float64(duration))
+		if local < 0 {
+			local += 2 * float64(duration)
+		}
+		if local > float64(duration) {
+			local = 2*float64(duration) - local
+		}
+	default:
+		local = math.Max(0, math.Min(local, float64(duration)))
+	}
+	return start + T(local)
+}
@@ -0,0 +1,103 @@
+package glm
+
+import "math"
+
+type AABB[T float] struct {
+	Min Vec3[T]
+	Max Vec3[T]
+}
+
+func AABBFromPoints[T float](points []Vec3[T]) AABB[T] {
+	if len(points) == 0 {
+		return AABB[T]{}
+	}
+
+	b := AABB[T]{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		b.Min = b.Min.Min(p)
+		b.Max = b.Max.Max(p)
+	}
+	return b
+}
+
+func (b AABB[T]) Center() Vec3[T] {
+	return b.Min.Add(b.Max).MulScalar(0.5)
+}
+
+// Extents returns the half size of the box along each axis.
+func (b AABB[T]) Extents() Vec3[T] {
+	return b.Max.Sub(b.Min).MulScalar(0.5)
+}
+
+func (b AABB[T]) Union(o AABB[T]) AABB[T] {
+	return AABB[T]{
+		Min: b.Min.Min(o.Min),
+		Max: b.Max.Max(o.Max),
+	}
+}
+
+func (b AABB[T]) ContainsPoint(p Vec3[T]) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] &&
+		p[1] >= b.Min[1] && p[1] <= b.Max[1] &&
+		p[2] >= b.Min[2] && p[2] <= b.Max[2]
+}
+
+func (b AABB[T]) Intersects(o AABB[T]) bool {
+	return b.Min[0] <= o.Max[0] && b.Max[0] >= o.Min[0] &&
+		b.Min[1] <= o.Max[1] && b.Max[1] >= o.Min[1] &&
+		b.Min[2] <= o.Max[2] && b.Max[2] >= o.Min[2]
+}
+
+// Transform returns the axis aligned box enclosing b
+// after it has been transformed by the affine matrix m.
+func (b AABB[T]) Transform(m Mat4[T]) AABB[T] {
+	c := m.TransformPoint(b.Center())
+	e := b.Extents()
+
+	var r Vec3[T]
+	for i := 0; i < 3; i++ {
+		r[i] = T(math.Abs(float64(m[i])))*e[0] +
+			T(math.Abs(float64(m[4+i])))*e[1] +
+			T(math.Abs(float64(m[8+i])))*e[2]
+	}
+
+	return AABB[T]{Min: c.Sub(r), Max: c.Add(r)}
+}
+
+func (b AABB[T]) BoundingSphere() Sphere[T] {
+	return Sphere[T]{
+		Center: b.Center(),
+		Radius: b.Extents().Magnitude(),
+	}
+}
+
+type Sphere[T float] struct {
+	Center Vec3[T]
+	Radius T
+}
+
+func (s Sphere[T]) ContainsPoint(p Vec3[T]) bool {
+	d := p.Sub(s.Center)
+	return d.Dot(d) <= s.Radius*s.Radius
+}
+
+func (s Sphere[T]) Intersects(o Sphere[T]) bool {
+	d := o.Center.Sub(s.Center)
+	r := s.Radius + o.Radius
+	return d.Dot(d) <= r*r
+}
+
+// Transform returns the sphere enclosing s after it has been
+// transformed by the affine matrix m, the radius is scaled by
+// the largest axis scale of m.
+func (s Sphere[T]) Transform(m Mat4[T]) Sphere[T] {
+	sx := Vec3[T]{m[0], m[1], m[2]}.Dot(Vec3[T]{m[0], m[1], m[2]})
+	sy := Vec3[T]{m[4], m[5], m[6]}.Dot(Vec3[T]{m[4], m[5], m[6]})
+	sz := Vec3[T]{m[8], m[9], m[10]}.Dot(Vec3[T]{m[8], m[9], m[10]})
+	scale := T(math.Sqrt(float64(maxf(sx, maxf(sy, sz)))))
+
+	return Sphere[T]{
+		Center: m.TransformPoint(s.Center),
+		Radius: s.Radius * scale,
+	}
+}
@@ -0,0 +1,184 @@
+package glm
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Color is an RGBA colour, whether it is sRGB encoded or linear
+// is up to the caller. Color[float64] can be converted directly
+// to wgpu.Color, e.g. wgpu.Color(c.Float64()).
+type Color[T float] struct {
+	R, G, B, A T
+}
+
+// SRGBToLinear decodes a single sRGB encoded channel in [0, 1]
+// using the exact piecewise transfer function.
+func SRGBToLinear[T float](c T) T {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return T(math.Pow((float64(c)+0.055)/1.055, 2.4))
+}
+
+// LinearToSRGB encodes a single linear channel in [0, 1]
+// using the exact piecewise transfer function.
+func LinearToSRGB[T float](c T) T {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return T(1.055*math.Pow(float64(c), 1/2.4) - 0.055)
+}
+
+// ColorFromHSV creates a colour from hue in degrees and
+// saturation, value and alpha in [0, 1].
+func ColorFromHSV[T float](h, s, v, a T) Color[T] {
+	c := v * s
+	return colorFromHueChroma(h, c, v-c, a)
+}
+
+// ColorFromHSL creates a colour from hue in degrees and
+// saturation, lightness and alpha in [0, 1].
+func ColorFromHSL[T float](h, s, l, a T) Color[T] {
+	c := (1 - T(math.Abs(float64(2*l-1)))) * s
+	return colorFromHueChroma(h, c, l-c/2, a)
+}
+
+func colorFromHueChroma[T float](h, c, m, a T) Color[T] {
+	h = T(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	hp := h / 60
+	x := c * (1 - T(math.Abs(math.Mod(float64(hp), 2)-1)))
+
+	var r, g, b T
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return Color[T]{r + m, g + m, b + m, a}
+}
+
+// ColorFromRGBA8 unpacks a colour packed by PackRGBA8.
+func ColorFromRGBA8[T float](packed uint32) Color[T] {
+	return Color[T]{
+		R: T(packed&0xFF) / 255,
+		G: T((packed>>8)&0xFF) / 255,
+		B: T((packed>>16)&0xFF) / 255,
+		A: T((packed>>24)&0xFF) / 255,
+	}
+}
+
+func (c Color[T]) ToLinear() Color[T] {
+	return Color[T]{SRGBToLinear(c.R), SRGBToLinear(c.G), SRGBToLinear(c.B), c.A}
+}
+
+func (c Color[T]) ToSRGB() Color[T] {
+	return Color[T]{LinearToSRGB(c.R), LinearToSRGB(c.G), LinearToSRGB(c.B), c.A}
+}
+
+// IsSRGBFormat reports whether the texture format, e.g. a
+// wgpu.TextureFormat, encodes shader output as sRGB on write.
+func IsSRGBFormat(format fmt.Stringer) bool {
+	return strings.HasSuffix(format.String(), "Srgb")
+}
+
+// ForFormat returns the sRGB encoded colour c as it is written to a
+// render target of the given format, linearized if the format encodes
+// on write.
+func (c Color[T]) ForFormat(format fmt.Stringer) Color[T] {
+	if IsSRGBFormat(format) {
+		return c.ToLinear()
+	}
+	return c
+}
+
+func (c Color[T]) Premultiply() Color[T] {
+	return Color[T]{c.R * c.A, c.G * c.A, c.B * c.A, c.A}
+}
+
+func (c Color[T]) Unpremultiply() Color[T] {
+	if c.A == 0 {
+		return Color[T]{}
+	}
+	return Color[T]{c.R / c.A, c.G / c.A, c.B / c.A, c.A}
+}
+
+// ToHSV returns hue in degrees and saturation and value in [0, 1].
+func (c Color[T]) ToHSV() (h, s, v T) {
+	cMax := maxf(c.R, maxf(c.G, c.B))
+	cMin := minf(c.R, minf(c.G, c.B))
+	d := cMax - cMin
+
+	if cMax != 0 {
+		s = d / cMax
+	}
+	return c.hue(cMax, d), s, cMax
+}
+
+// ToHSL returns hue in degrees and saturation and lightness in [0, 1].
+func (c Color[T]) ToHSL() (h, s, l T) {
+	cMax := maxf(c.R, maxf(c.G, c.B))
+	cMin := minf(c.R, minf(c.G, c.B))
+	d := cMax - cMin
+
+	l = (cMax + cMin) / 2
+	if d != 0 {
+		s = d / (1 - T(math.Abs(float64(2*l-1))))
+	}
+	return c.hue(cMax, d), s, l
+}
+
+func (c Color[T]) hue(cMax, d T) (h T) {
+	switch {
+	case d == 0:
+		return 0
+	case cMax == c.R:
+		h = 60 * T(math.Mod(float64((c.G-c.B)/d), 6))
+	case cMax == c.G:
+		h = 60 * ((c.B-c.R)/d + 2)
+	default:
+		h = 60 * ((c.R-c.G)/d + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// PackRGBA8 packs the colour, clamped to [0, 1], with R in the lowest
+// byte, matching the memory layout of TextureFormat_RGBA8Unorm.
+func (c Color[T]) PackRGBA8() uint32 {
+	return uint32(unormToU8(c.R)) |
+		uint32(unormToU8(c.G))<<8 |
+		uint32(unormToU8(c.B))<<16 |
+		uint32(unormToU8(c.A))<<24
+}
+
+func unormToU8[T float](v T) uint8 {
+	return uint8(math.Round(float64(maxf(0, minf(v, 1))) * 255))
+}
+
+func (c Color[T]) Float64() Color[float64] {
+	return Color[float64]{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+}
+
+func (c Color[T]) Vec3() Vec3[T] {
+	return Vec3[T]{c.R, c.G, c.B}
+}
+
+func (c Color[T]) Vec4() Vec4[T] {
+	return Vec4[T]{c.R, c.G, c.B, c.A}
+}
@@ -3,3 +3,17 @@ package glm
 type float interface {
 	~float32 | ~float64
 }
+
+func minf[T float](a, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf[T float](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,103 @@
+package glm
+
+// Plane is the set of points p where Normal.Dot(p) + D == 0,
+// Normal points towards the positive half-space.
+type Plane[T float] struct {
+	Normal Vec3[T]
+	D      T
+}
+
+func (p Plane[T]) Normalize() Plane[T] {
+	m := p.Normal.Magnitude()
+	if m == 0 {
+		return p
+	}
+	return Plane[T]{
+		Normal: p.Normal.MulScalar(1 / m),
+		D:      p.D / m,
+	}
+}
+
+// Distance returns the signed distance of pt from the plane,
+// the plane must be normalized.
+func (p Plane[T]) Distance(pt Vec3[T]) T {
+	return p.Normal.Dot(pt) + p.D
+}
+
+const (
+	FrustumLeft = iota
+	FrustumRight
+	FrustumBottom
+	FrustumTop
+	FrustumNear
+	FrustumFar
+)
+
+// Frustum holds the six planes of a view volume,
+// each pointing towards the inside.
+type Frustum[T float] struct {
+	Planes [6]Plane[T]
+}
+
+// FrustumFromMat4 extracts the frustum planes from a view-projection
+// matrix that maps depth to [0, 1]. Reverse-Z and infinite far
+// projections are supported, for the latter the far plane never
+// rejects anything.
+func FrustumFromMat4[T float](m Mat4[T]) Frustum[T] {
+	r0, r1, r2, r3 := m.Row(0), m.Row(1), m.Row(2), m.Row(3)
+
+	plane := func(v Vec4[T]) Plane[T] {
+		return Plane[T]{Normal: v.Truncate(), D: v[3]}.Normalize()
+	}
+
+	return Frustum[T]{
+		Planes: [6]Plane[T]{
+			FrustumLeft:   plane(r3.Add(r0)),
+			FrustumRight:  plane(r3.Sub(r0)),
+			FrustumBottom: plane(r3.Add(r1)),
+			FrustumTop:    plane(r3.Sub(r1)),
+			FrustumNear:   plane(r2),
+			FrustumFar:    plane(r3.Sub(r2)),
+		},
+	}
+}
+
+func (f Frustum[T]) ContainsPoint(p Vec3[T]) bool {
+	for _, plane := range f.Planes {
+		if plane.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsSphere reports whether s is inside or overlaps the frustum.
+// It is conservative and can return true for spheres near the corners.
+func (f Frustum[T]) IntersectsSphere(s Sphere[T]) bool {
+	for _, plane := range f.Planes {
+		if plane.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB reports whether b is inside or overlaps the frustum.
+// It is conservative and can return true for boxes near the corners.
+func (f Frustum[T]) IntersectsAABB(b AABB[T]) bool {
+	for _, plane := range f.Planes {
+		// the corner furthest along the plane normal
+		var p Vec3[T]
+		for i := 0; i < 3; i++ {
+			if plane.Normal[i] >= 0 {
+				p[i] = b.Max[i]
+			} else {
+				p[i] = b.Min[i]
+			}
+		}
+		if plane.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
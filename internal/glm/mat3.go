@@ -0,0 +1,82 @@
+package glm
+
+type Mat3[T float] [9]T
+
+func Mat3Identity[T float]() Mat3[T] {
+	return Mat3[T]{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	}
+}
+
+func Mat3FromQuaternion[T float](quat Quaternion[T]) Mat3[T] {
+	return Mat4FromQuaternion(quat).Mat3()
+}
+
+func (lhs Mat3[T]) Mul3(rhs Mat3[T]) Mat3[T] {
+	return Mat3[T]{
+		lhs[0]*rhs[0] + lhs[3]*rhs[1] + lhs[6]*rhs[2],
+		lhs[1]*rhs[0] + lhs[4]*rhs[1] + lhs[7]*rhs[2],
+		lhs[2]*rhs[0] + lhs[5]*rhs[1] + lhs[8]*rhs[2],
+		lhs[0]*rhs[3] + lhs[3]*rhs[4] + lhs[6]*rhs[5],
+		lhs[1]*rhs[3] + lhs[4]*rhs[4] + lhs[7]*rhs[5],
+		lhs[2]*rhs[3] + lhs[5]*rhs[4] + lhs[8]*rhs[5],
+		lhs[0]*rhs[6] + lhs[3]*rhs[7] + lhs[6]*rhs[8],
+		lhs[1]*rhs[6] + lhs[4]*rhs[7] + lhs[7]*rhs[8],
+		lhs[2]*rhs[6] + lhs[5]*rhs[7] + lhs[8]*rhs[8],
+	}
+}
+
+func (lhs Mat3[T]) MulVec3(rhs Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		lhs[0]*rhs[0] + lhs[3]*rhs[1] + lhs[6]*rhs[2],
+		lhs[1]*rhs[0] + lhs[4]*rhs[1] + lhs[7]*rhs[2],
+		lhs[2]*rhs[0] + lhs[5]*rhs[1] + lhs[8]*rhs[2],
+	}
+}
+
+func (lhs Mat3[T]) Transpose() Mat3[T] {
+	return Mat3[T]{
+		lhs[0], lhs[3], lhs[6],
+		lhs[1], lhs[4], lhs[7],
+		lhs[2], lhs[5], lhs[8],
+	}
+}
+
+func (lhs Mat3[T]) Determinant() T {
+	return lhs[0]*(lhs[4]*lhs[8]-lhs[7]*lhs[5]) -
+		lhs[3]*(lhs[1]*lhs[8]-lhs[7]*lhs[2]) +
+		lhs[6]*(lhs[1]*lhs[5]-lhs[4]*lhs[2])
+}
+
+// Inverse returns the inverse of the matrix, ok is false
+// if the matrix is singular and can't be inverted.
+func (lhs Mat3[T]) Inverse() (inv Mat3[T], ok bool) {
+	det := lhs.Determinant()
+	if det == 0 {
+		return Mat3[T]{}, false
+	}
+	invDet := 1 / det
+
+	return Mat3[T]{
+		(lhs[4]*lhs[8] - lhs[7]*lhs[5]) * invDet,
+		(lhs[7]*lhs[2] - lhs[1]*lhs[8]) * invDet,
+		(lhs[1]*lhs[5] - lhs[4]*lhs[2]) * invDet,
+		(lhs[6]*lhs[5] - lhs[3]*lhs[8]) * invDet,
+		(lhs[0]*lhs[8] - lhs[6]*lhs[2]) * invDet,
+		(lhs[3]*lhs[2] - lhs[0]*lhs[5]) * invDet,
+		(lhs[3]*lhs[7] - lhs[6]*lhs[4]) * invDet,
+		(lhs[6]*lhs[1] - lhs[0]*lhs[7]) * invDet,
+		(lhs[0]*lhs[4] - lhs[3]*lhs[1]) * invDet,
+	}, true
+}
+
+func (lhs Mat3[T]) Mat4() Mat4[T] {
+	return Mat4[T]{
+		lhs[0], lhs[1], lhs[2], 0,
+		lhs[3], lhs[4], lhs[5], 0,
+		lhs[6], lhs[7], lhs[8], 0,
+		0, 0, 0, 1,
+	}
+}
@@ -69,3 +69,190 @@ func (lhs Mat4[T]) Mul4(rhs Mat4[T]) Mat4[T] {
 		lhs[3]*rhs[12] + lhs[7]*rhs[13] + lhs[11]*rhs[14] + lhs[15]*rhs[15],
 	}
 }
+
+func Mat4Identity[T float]() Mat4[T] {
+	return Mat4[T]{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+func (lhs Mat4[T]) Col(i int) Vec4[T] {
+	return Vec4[T]{lhs[i*4+0], lhs[i*4+1], lhs[i*4+2], lhs[i*4+3]}
+}
+
+func (lhs Mat4[T]) Row(i int) Vec4[T] {
+	return Vec4[T]{lhs[i], lhs[i+4], lhs[i+8], lhs[i+12]}
+}
+
+func (lhs Mat4[T]) MulVec4(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		lhs[0]*rhs[0] + lhs[4]*rhs[1] + lhs[8]*rhs[2] + lhs[12]*rhs[3],
+		lhs[1]*rhs[0] + lhs[5]*rhs[1] + lhs[9]*rhs[2] + lhs[13]*rhs[3],
+		lhs[2]*rhs[0] + lhs[6]*rhs[1] + lhs[10]*rhs[2] + lhs[14]*rhs[3],
+		lhs[3]*rhs[0] + lhs[7]*rhs[1] + lhs[11]*rhs[2] + lhs[15]*rhs[3],
+	}
+}
+
+// TransformPoint transforms p as a position (w = 1), dividing
+// by the resulting w so projective matrices are handled too.
+func (lhs Mat4[T]) TransformPoint(p Vec3[T]) Vec3[T] {
+	v := lhs.MulVec4(p.Extend(1))
+	if v[3] != 1 && v[3] != 0 {
+		return v.Truncate().DivScalar(v[3])
+	}
+	return v.Truncate()
+}
+
+// TransformDirection transforms d as a direction (w = 0),
+// ignoring the translation part of the matrix.
+func (lhs Mat4[T]) TransformDirection(d Vec3[T]) Vec3[T] {
+	return lhs.MulVec4(d.Extend(0)).Truncate()
+}
+
+func (lhs Mat4[T]) Transpose() Mat4[T] {
+	return Mat4[T]{
+		lhs[0], lhs[4], lhs[8], lhs[12],
+		lhs[1], lhs[5], lhs[9], lhs[13],
+		lhs[2], lhs[6], lhs[10], lhs[14],
+		lhs[3], lhs[7], lhs[11], lhs[15],
+	}
+}
+
+func (lhs Mat4[T]) Determinant() T {
+	s0 := lhs[0]*lhs[5] - lhs[4]*lhs[1]
+	s1 := lhs[0]*lhs[6] - lhs[4]*lhs[2]
+	s2 := lhs[0]*lhs[7] - lhs[4]*lhs[3]
+	s3 := lhs[1]*lhs[6] - lhs[5]*lhs[2]
+	s4 := lhs[1]*lhs[7] - lhs[5]*lhs[3]
+	s5 := lhs[2]*lhs[7] - lhs[6]*lhs[3]
+
+	c5 := lhs[10]*lhs[15] - lhs[14]*lhs[11]
+	c4 := lhs[9]*lhs[15] - lhs[13]*lhs[11]
+	c3 := lhs[9]*lhs[14] - lhs[13]*lhs[10]
+	c2 := lhs[8]*lhs[15] - lhs[12]*lhs[11]
+	c1 := lhs[8]*lhs[14] - lhs[12]*lhs[10]
+	c0 := lhs[8]*lhs[13] - lhs[12]*lhs[9]
+
+	return s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+}
+
+// Inverse returns the inverse of the matrix, ok is false
+// if the matrix is singular and can't be inverted.
+func (lhs Mat4[T]) Inverse() (inv Mat4[T], ok bool) {
+	s0 := lhs[0]*lhs[5] - lhs[4]*lhs[1]
+	s1 := lhs[0]*lhs[6] - lhs[4]*lhs[2]
+	s2 := lhs[0]*lhs[7] - lhs[4]*lhs[3]
+	s3 := lhs[1]*lhs[6] - lhs[5]*lhs[2]
+	s4 := lhs[1]*lhs[7] - lhs[5]*lhs[3]
+	s5 := lhs[2]*lhs[7] - lhs[6]*lhs[3]
+
+	c5 := lhs[10]*lhs[15] - lhs[14]*lhs[11]
+	c4 := lhs[9]*lhs[15] - lhs[13]*lhs[11]
+	c3 := lhs[9]*lhs[14] - lhs[13]*lhs[10]
+	c2 := lhs[8]*lhs[15] - lhs[12]*lhs[11]
+	c1 := lhs[8]*lhs[14] - lhs[12]*lhs[10]
+	c0 := lhs[8]*lhs[13] - lhs[12]*lhs[9]
+
+	det := s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+	if det == 0 {
+		return Mat4[T]{}, false
+	}
+	invDet := 1 / det
+
+	return Mat4[T]{
+		(lhs[5]*c5 - lhs[6]*c4 + lhs[7]*c3) * invDet,
+		(-lhs[1]*c5 + lhs[2]*c4 - lhs[3]*c3) * invDet,
+		(lhs[13]*s5 - lhs[14]*s4 + lhs[15]*s3) * invDet,
+		(-lhs[9]*s5 + lhs[10]*s4 - lhs[11]*s3) * invDet,
+
+		(-lhs[4]*c5 + lhs[6]*c2 - lhs[7]*c1) * invDet,
+		(lhs[0]*c5 - lhs[2]*c2 + lhs[3]*c1) * invDet,
+		(-lhs[12]*s5 + lhs[14]*s2 - lhs[15]*s1) * invDet,
+		(lhs[8]*s5 - lhs[10]*s2 + lhs[11]*s1) * invDet,
+
+		(lhs[4]*c4 - lhs[5]*c2 + lhs[7]*c0) * invDet,
+		(-lhs[0]*c4 + lhs[1]*c2 - lhs[3]*c0) * invDet,
+		(lhs[12]*s4 - lhs[13]*s2 + lhs[15]*s0) * invDet,
+		(-lhs[8]*s4 + lhs[9]*s2 - lhs[11]*s0) * invDet,
+
+		(-lhs[4]*c3 + lhs[5]*c1 - lhs[6]*c0) * invDet,
+		(lhs[0]*c3 - lhs[1]*c1 + lhs[2]*c0) * invDet,
+		(-lhs[12]*s3 + lhs[13]*s1 - lhs[14]*s0) * invDet,
+		(lhs[8]*s3 - lhs[9]*s1 + lhs[10]*s0) * invDet,
+	}, true
+}
+
+// Mat3 returns the upper-left 3x3 part of the matrix.
+func (lhs Mat4[T]) Mat3() Mat3[T] {
+	return Mat3[T]{
+		lhs[0], lhs[1], lhs[2],
+		lhs[4], lhs[5], lhs[6],
+		lhs[8], lhs[9], lhs[10],
+	}
+}
+
+// NormalMatrix returns the inverse-transpose of the upper-left 3x3
+// part of the matrix, used to transform normals by a model matrix
+// that may contain non-uniform scale. If that part is singular
+// the plain 3x3 part is returned.
+func (lhs Mat4[T]) NormalMatrix() Mat3[T] {
+	m := lhs.Mat3()
+	inv, ok := m.Inverse()
+	if !ok {
+		return m
+	}
+	return inv.Transpose()
+}
+
+func Mat4FromScale[T float](v Vec3[T]) Mat4[T] {
+	return Mat4[T]{
+		v[0], 0, 0, 0,
+		0, v[1], 0, 0,
+		0, 0, v[2], 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Mat4FromTRS returns translation * rotation * scale.
+func Mat4FromTRS[T float](translation Vec3[T], rotation Quaternion[T], scale Vec3[T]) Mat4[T] {
+	m := Mat4FromQuaternion(rotation)
+	for i := 0; i < 4; i++ {
+		m[i] *= scale[0]
+		m[4+i] *= scale[1]
+		m[8+i] *= scale[2]
+	}
+	m[12], m[13], m[14] = translation[0], translation[1], translation[2]
+	return m
+}
+
+// Decompose splits an affine matrix without shear into the
+// translation, rotation and scale that Mat4FromTRS would combine.
+// A negative determinant is represented by a negative x scale.
+func (lhs Mat4[T]) Decompose() (translation Vec3[T], rotation Quaternion[T], scale Vec3[T]) {
+	translation = Vec3[T]{lhs[12], lhs[13], lhs[14]}
+
+	x := Vec3[T]{lhs[0], lhs[1], lhs[2]}
+	y := Vec3[T]{lhs[4], lhs[5], lhs[6]}
+	z := Vec3[T]{lhs[8], lhs[9], lhs[10]}
+	scale = Vec3[T]{x.Magnitude(), y.Magnitude(), z.Magnitude()}
+	if lhs.Mat3().Determinant() < 0 {
+		scale[0] = -scale[0]
+	}
+
+	if scale[0] == 0 || scale[1] == 0 || scale[2] == 0 {
+		return translation, QuaternionIdentity[T](), scale
+	}
+
+	x = x.DivScalar(scale[0])
+	y = y.DivScalar(scale[1])
+	z = z.DivScalar(scale[2])
+	rotation = QuaternionFromMat3(Mat3[T]{
+		x[0], x[1], x[2],
+		y[0], y[1], y[2],
+		z[0], z[1], z[2],
+	})
+	return translation, rotation, scale
+}
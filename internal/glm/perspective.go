@@ -16,6 +16,108 @@ func PerspectiveRH[T float](fovYrad, aspectRatio, zNear, zFar T) Mat4[T] {
 	}
 }
 
+func PerspectiveLH[T float](fovYrad, aspectRatio, zNear, zFar T) Mat4[T] {
+	sinFov, cosFov := math.Sincos(float64(0.5) * float64(fovYrad))
+	h := T(cosFov) / T(sinFov)
+	w := h / aspectRatio
+	r := zFar / (zFar - zNear)
+
+	return Mat4[T]{
+		w, 0, 0, 0,
+		0, h, 0, 0,
+		0, 0, r, 1,
+		0, 0, -r * zNear, 0,
+	}
+}
+
+// PerspectiveReverseZRH maps zNear to depth 1 and zFar to depth 0,
+// use it with a CompareFunction_Greater depth test and a depth
+// clear value of 0.
+func PerspectiveReverseZRH[T float](fovYrad, aspectRatio, zNear, zFar T) Mat4[T] {
+	sinFov, cosFov := math.Sincos(float64(0.5) * float64(fovYrad))
+	h := T(cosFov) / T(sinFov)
+	w := h / aspectRatio
+	r := zNear / (zFar - zNear)
+
+	return Mat4[T]{
+		w, 0, 0, 0,
+		0, h, 0, 0,
+		0, 0, r, -1,
+		0, 0, r * zFar, 0,
+	}
+}
+
+func PerspectiveInfiniteRH[T float](fovYrad, aspectRatio, zNear T) Mat4[T] {
+	sinFov, cosFov := math.Sincos(float64(0.5) * float64(fovYrad))
+	h := T(cosFov) / T(sinFov)
+	w := h / aspectRatio
+
+	return Mat4[T]{
+		w, 0, 0, 0,
+		0, h, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -zNear, 0,
+	}
+}
+
+// PerspectiveInfiniteReverseZRH maps zNear to depth 1 and infinity
+// to depth 0, see PerspectiveReverseZRH.
+func PerspectiveInfiniteReverseZRH[T float](fovYrad, aspectRatio, zNear T) Mat4[T] {
+	sinFov, cosFov := math.Sincos(float64(0.5) * float64(fovYrad))
+	h := T(cosFov) / T(sinFov)
+	w := h / aspectRatio
+
+	return Mat4[T]{
+		w, 0, 0, 0,
+		0, h, 0, 0,
+		0, 0, 0, -1,
+		0, 0, zNear, 0,
+	}
+}
+
+// FrustumRH returns an off-center perspective projection, the
+// extents are specified on the near plane.
+func FrustumRH[T float](left, right, bottom, top, zNear, zFar T) Mat4[T] {
+	rl := 1 / (right - left)
+	tb := 1 / (top - bottom)
+	r := zFar / (zNear - zFar)
+
+	return Mat4[T]{
+		2 * zNear * rl, 0, 0, 0,
+		0, 2 * zNear * tb, 0, 0,
+		(right + left) * rl, (top + bottom) * tb, r, -1,
+		0, 0, r * zNear, 0,
+	}
+}
+
+func OrthographicRH[T float](left, right, bottom, top, zNear, zFar T) Mat4[T] {
+	rl := 1 / (right - left)
+	tb := 1 / (top - bottom)
+	fn := 1 / (zFar - zNear)
+
+	return Mat4[T]{
+		2 * rl, 0, 0, 0,
+		0, 2 * tb, 0, 0,
+		0, 0, -fn, 0,
+		-(right + left) * rl, -(top + bottom) * tb, -zNear * fn, 1,
+	}
+}
+
+func OrthographicLH[T float](left, right, bottom, top, zNear, zFar T) Mat4[T] {
+	rl := 1 / (right - left)
+	tb := 1 / (top - bottom)
+	fn := 1 / (zFar - zNear)
+
+	return Mat4[T]{
+		2 * rl, 0, 0, 0,
+		0, 2 * tb, 0, 0,
+		0, 0, fn, 0,
+		-(right + left) * rl, -(top + bottom) * tb, -zNear * fn, 1,
+	}
+}
+
+// Perspective returns an OpenGL style projection with a [-1, 1] depth
+// range, prefer PerspectiveRH which targets WebGPU's [0, 1] range.
 func Perspective[T float](fovYRad, aspect, near, far T) Mat4[T] {
 	f := T(1 / math.Tan(float64(fovYRad*0.5)))
 
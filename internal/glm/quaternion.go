@@ -25,3 +25,167 @@ func (lhs Quaternion[T]) Mul(rhs Quaternion[T]) Quaternion[T] {
 		},
 	}
 }
+
+func QuaternionIdentity[T float]() Quaternion[T] {
+	return Quaternion[T]{S: 1}
+}
+
+// QuaternionFromEuler creates a rotation from euler angles in radians.
+// Rotations are applied in X, Y, Z order about the fixed world axes,
+// i.e. the result is equivalent to qz * qy * qx.
+func QuaternionFromEuler[T float](xRad, yRad, zRad T) Quaternion[T] {
+	sx, cx := math.Sincos(float64(xRad) * 0.5)
+	sy, cy := math.Sincos(float64(yRad) * 0.5)
+	sz, cz := math.Sincos(float64(zRad) * 0.5)
+
+	return Quaternion[T]{
+		S: T(cx*cy*cz + sx*sy*sz),
+		V: Vec3[T]{
+			T(sx*cy*cz - cx*sy*sz),
+			T(cx*sy*cz + sx*cy*sz),
+			T(cx*cy*sz - sx*sy*cz),
+		},
+	}
+}
+
+// QuaternionFromMat3 extracts the rotation from a pure rotation matrix.
+func QuaternionFromMat3[T float](m Mat3[T]) Quaternion[T] {
+	m00, m01, m02 := float64(m[0]), float64(m[3]), float64(m[6])
+	m10, m11, m12 := float64(m[1]), float64(m[4]), float64(m[7])
+	m20, m21, m22 := float64(m[2]), float64(m[5]), float64(m[8])
+
+	var s, x, y, z float64
+	switch trace := m00 + m11 + m22; {
+	case trace > 0:
+		t := math.Sqrt(trace+1) * 2
+		s, x, y, z = t/4, (m21-m12)/t, (m02-m20)/t, (m10-m01)/t
+	case m00 > m11 && m00 > m22:
+		t := math.Sqrt(1+m00-m11-m22) * 2
+		s, x, y, z = (m21-m12)/t, t/4, (m01+m10)/t, (m02+m20)/t
+	case m11 > m22:
+		t := math.Sqrt(1+m11-m00-m22) * 2
+		s, x, y, z = (m02-m20)/t, (m01+m10)/t, t/4, (m12+m21)/t
+	default:
+		t := math.Sqrt(1+m22-m00-m11) * 2
+		s, x, y, z = (m10-m01)/t, (m02+m20)/t, (m12+m21)/t, t/4
+	}
+
+	return Quaternion[T]{S: T(s), V: Vec3[T]{T(x), T(y), T(z)}}
+}
+
+// QuaternionFromMat4 extracts the rotation part of an affine
+// transform, any scale in the upper-left 3x3 part is removed first.
+func QuaternionFromMat4[T float](m Mat4[T]) Quaternion[T] {
+	x := Vec3[T]{m[0], m[1], m[2]}.Normalize()
+	y := Vec3[T]{m[4], m[5], m[6]}.Normalize()
+	z := Vec3[T]{m[8], m[9], m[10]}.Normalize()
+
+	return QuaternionFromMat3(Mat3[T]{
+		x[0], x[1], x[2],
+		y[0], y[1], y[2],
+		z[0], z[1], z[2],
+	})
+}
+
+// QuaternionLookRotation returns the rotation that points the
+// -Z axis along forward and keeps +Y as close to up as possible,
+// matching the convention used by LookAtRH.
+func QuaternionLookRotation[T float](forward, up Vec3[T]) Quaternion[T] {
+	z := forward.Normalize().Neg()
+	x := up.Cross(z).Normalize()
+	y := z.Cross(x)
+
+	return QuaternionFromMat3(Mat3[T]{
+		x[0], x[1], x[2],
+		y[0], y[1], y[2],
+		z[0], z[1], z[2],
+	})
+}
+
+func (lhs Quaternion[T]) Dot(rhs Quaternion[T]) T {
+	return lhs.S*rhs.S + lhs.V.Dot(rhs.V)
+}
+
+func (lhs Quaternion[T]) Magnitude() T {
+	return T(math.Sqrt(float64(lhs.Dot(lhs))))
+}
+
+func (lhs Quaternion[T]) MulScalar(s T) Quaternion[T] {
+	return Quaternion[T]{
+		S: lhs.S * s,
+		V: lhs.V.MulScalar(s),
+	}
+}
+
+func (lhs Quaternion[T]) Add(rhs Quaternion[T]) Quaternion[T] {
+	return Quaternion[T]{
+		S: lhs.S + rhs.S,
+		V: lhs.V.Add(rhs.V),
+	}
+}
+
+func (lhs Quaternion[T]) Normalize() Quaternion[T] {
+	return lhs.MulScalar(1 / lhs.Magnitude())
+}
+
+func (lhs Quaternion[T]) Conjugate() Quaternion[T] {
+	return Quaternion[T]{
+		S: lhs.S,
+		V: lhs.V.Neg(),
+	}
+}
+
+func (lhs Quaternion[T]) Inverse() Quaternion[T] {
+	return lhs.Conjugate().MulScalar(1 / lhs.Dot(lhs))
+}
+
+// ToEuler returns the euler angles in radians, in the same
+// order as QuaternionFromEuler expects them.
+func (lhs Quaternion[T]) ToEuler() (xRad, yRad, zRad T) {
+	s, x, y, z := float64(lhs.S), float64(lhs.V[0]), float64(lhs.V[1]), float64(lhs.V[2])
+
+	sinY := 2 * (s*y - z*x)
+	if sinY > 1 {
+		sinY = 1
+	} else if sinY < -1 {
+		sinY = -1
+	}
+
+	return T(math.Atan2(2*(s*x+y*z), 1-2*(x*x+y*y))),
+		T(math.Asin(sinY)),
+		T(math.Atan2(2*(s*z+x*y), 1-2*(y*y+z*z)))
+}
+
+func (lhs Quaternion[T]) RotateVec3(v Vec3[T]) Vec3[T] {
+	t := lhs.V.Cross(v).MulScalar(2)
+	return v.Add(t.MulScalar(lhs.S)).Add(lhs.V.Cross(t))
+}
+
+// Nlerp linearly interpolates between lhs and rhs along the
+// shortest path and normalizes the result.
+func (lhs Quaternion[T]) Nlerp(rhs Quaternion[T], t T) Quaternion[T] {
+	if lhs.Dot(rhs) < 0 {
+		rhs = rhs.MulScalar(-1)
+	}
+	return lhs.MulScalar(1 - t).Add(rhs.MulScalar(t)).Normalize()
+}
+
+// Slerp spherically interpolates between lhs and rhs along the
+// shortest path, falling back to Nlerp when they are nearly equal.
+func (lhs Quaternion[T]) Slerp(rhs Quaternion[T], t T) Quaternion[T] {
+	dot := lhs.Dot(rhs)
+	if dot < 0 {
+		rhs = rhs.MulScalar(-1)
+		dot = -dot
+	}
+	if dot > 0.9995 {
+		return lhs.Nlerp(rhs, t)
+	}
+
+	theta := math.Acos(float64(dot))
+	sinTheta := math.Sin(theta)
+	a := T(math.Sin((1-float64(t))*theta) / sinTheta)
+	b := T(math.Sin(float64(t)*theta) / sinTheta)
+
+	return lhs.MulScalar(a).Add(rhs.MulScalar(b))
+}
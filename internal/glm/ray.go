@@ -0,0 +1,157 @@
+package glm
+
+import "math"
+
+type Ray[T float] struct {
+	Origin    Vec3[T]
+	Direction Vec3[T]
+}
+
+func (r Ray[T]) At(t T) Vec3[T] {
+	return r.Origin.Add(r.Direction.MulScalar(t))
+}
+
+// Transform transforms the ray by the affine matrix m. The direction
+// is not re-normalized so distances along the transformed ray match
+// distances along the original one.
+func (r Ray[T]) Transform(m Mat4[T]) Ray[T] {
+	return Ray[T]{
+		Origin:    m.TransformPoint(r.Origin),
+		Direction: m.TransformDirection(r.Direction),
+	}
+}
+
+// Unproject maps a window point back to world space. win[0] and win[1]
+// are in pixels with the origin at the top-left corner, win[2] is the
+// depth in [0, 1].
+func Unproject[T float](win Vec3[T], invViewProj Mat4[T], width, height T) Vec3[T] {
+	ndc := Vec4[T]{
+		2*win[0]/width - 1,
+		1 - 2*win[1]/height,
+		win[2],
+		1,
+	}
+	p := invViewProj.MulVec4(ndc)
+	return p.Truncate().DivScalar(p[3])
+}
+
+// RayFromScreen returns the world space ray going through the window
+// point (x, y) in pixels. For perspective projections the ray starts at
+// the eye, for orthographic ones it starts on the depth 0 plane.
+func RayFromScreen[T float](x, y, width, height T, invViewProj Mat4[T]) Ray[T] {
+	eye := invViewProj.MulVec4(Vec4[T]{0, 0, 1, 0})
+	if eye[3] != 0 {
+		origin := eye.Truncate().DivScalar(eye[3])
+		p := Unproject(Vec3[T]{x, y, 0.5}, invViewProj, width, height)
+		return Ray[T]{
+			Origin:    origin,
+			Direction: p.Sub(origin).Normalize(),
+		}
+	}
+
+	near := Unproject(Vec3[T]{x, y, 0}, invViewProj, width, height)
+	far := Unproject(Vec3[T]{x, y, 1}, invViewProj, width, height)
+	return Ray[T]{
+		Origin:    near,
+		Direction: far.Sub(near).Normalize(),
+	}
+}
+
+func (r Ray[T]) IntersectPlane(p Plane[T]) (t T, ok bool) {
+	denom := p.Normal.Dot(r.Direction)
+	if denom == 0 {
+		return 0, false
+	}
+	t = -(p.Normal.Dot(r.Origin) + p.D) / denom
+	return t, t >= 0
+}
+
+// IntersectAABB returns the distance to where the ray enters b,
+// or 0 if the origin is inside b.
+func (r Ray[T]) IntersectAABB(b AABB[T]) (t T, ok bool) {
+	tMin := T(0)
+	tMax := T(math.Inf(1))
+
+	for i := 0; i < 3; i++ {
+		if r.Direction[i] == 0 {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return 0, false
+			}
+			continue
+		}
+
+		inv := 1 / r.Direction[i]
+		t0 := (b.Min[i] - r.Origin[i]) * inv
+		t1 := (b.Max[i] - r.Origin[i]) * inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		tMin = maxf(tMin, t0)
+		tMax = minf(tMax, t1)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+
+	return tMin, true
+}
+
+// IntersectSphere returns the distance to where the ray enters s,
+// or 0 if the origin is inside s.
+func (r Ray[T]) IntersectSphere(s Sphere[T]) (t T, ok bool) {
+	oc := r.Origin.Sub(s.Center)
+	a := r.Direction.Dot(r.Direction)
+	b := oc.Dot(r.Direction)
+	c := oc.Dot(oc) - s.Radius*s.Radius
+
+	disc := b*b - a*c
+	if disc < 0 {
+		return 0, false
+	}
+	sqrtDisc := T(math.Sqrt(float64(disc)))
+
+	t = (-b - sqrtDisc) / a
+	if t < 0 {
+		t = (-b + sqrtDisc) / a
+		if t < 0 {
+			return 0, false
+		}
+		return 0, true
+	}
+	return t, true
+}
+
+// IntersectTriangle implements the Möller–Trumbore algorithm, u and v
+// are the barycentric coordinates of the hit relative to b and c.
+// Both front and back faces are reported.
+func (r Ray[T]) IntersectTriangle(a, b, c Vec3[T]) (t, u, v T, ok bool) {
+	const epsilon = 1e-7
+
+	edge1 := b.Sub(a)
+	edge2 := c.Sub(a)
+	pvec := r.Direction.Cross(edge2)
+	det := edge1.Dot(pvec)
+	if det > -epsilon && det < epsilon {
+		return 0, 0, 0, false
+	}
+	invDet := 1 / det
+
+	tvec := r.Origin.Sub(a)
+	u = tvec.Dot(pvec) * invDet
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	qvec := tvec.Cross(edge1)
+	v = r.Direction.Dot(qvec) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+
+	t = edge2.Dot(qvec) * invDet
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
@@ -0,0 +1,95 @@
+package glm
+
+import "math"
+
+type Vec2[T float] [2]T
+
+func (lhs Vec2[T]) Dot(rhs Vec2[T]) T {
+	return (lhs[0] * rhs[0]) + (lhs[1] * rhs[1])
+}
+
+func (lhs Vec2[T]) Magnitude() T {
+	return T(math.Sqrt(float64(lhs.Dot(lhs))))
+}
+
+func (lhs Vec2[T]) MulScalar(s T) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] * s,
+		lhs[1] * s,
+	}
+}
+
+func (lhs Vec2[T]) DivScalar(s T) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] / s,
+		lhs[1] / s,
+	}
+}
+
+func (lhs Vec2[T]) Normalize() Vec2[T] {
+	return lhs.MulScalar(1 / lhs.Magnitude())
+}
+
+// Cross returns the z component of the cross product of
+// lhs and rhs extended to 3D (the perp dot product).
+func (lhs Vec2[T]) Cross(rhs Vec2[T]) T {
+	return lhs[0]*rhs[1] - rhs[0]*lhs[1]
+}
+
+func (lhs Vec2[T]) Add(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] + rhs[0],
+		lhs[1] + rhs[1],
+	}
+}
+
+func (lhs Vec2[T]) Sub(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] - rhs[0],
+		lhs[1] - rhs[1],
+	}
+}
+
+func (lhs Vec2[T]) Mul(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] * rhs[0],
+		lhs[1] * rhs[1],
+	}
+}
+
+func (lhs Vec2[T]) Div(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		lhs[0] / rhs[0],
+		lhs[1] / rhs[1],
+	}
+}
+
+func (lhs Vec2[T]) Neg() Vec2[T] {
+	return Vec2[T]{-lhs[0], -lhs[1]}
+}
+
+func (lhs Vec2[T]) Lerp(rhs Vec2[T], t T) Vec2[T] {
+	return lhs.Add(rhs.Sub(lhs).MulScalar(t))
+}
+
+func (lhs Vec2[T]) Min(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		minf(lhs[0], rhs[0]),
+		minf(lhs[1], rhs[1]),
+	}
+}
+
+func (lhs Vec2[T]) Max(rhs Vec2[T]) Vec2[T] {
+	return Vec2[T]{
+		maxf(lhs[0], rhs[0]),
+		maxf(lhs[1], rhs[1]),
+	}
+}
+
+func (lhs Vec2[T]) Distance(rhs Vec2[T]) T {
+	return rhs.Sub(lhs).Magnitude()
+}
+
+func (lhs Vec2[T]) Extend(z T) Vec3[T] {
+	return Vec3[T]{lhs[0], lhs[1], z}
+}
@@ -47,3 +47,63 @@ func (lhs Vec3[T]) Sub(rhs Vec3[T]) Vec3[T] {
 		lhs[2] - rhs[2],
 	}
 }
+
+func (lhs Vec3[T]) Mul(rhs Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		lhs[0] * rhs[0],
+		lhs[1] * rhs[1],
+		lhs[2] * rhs[2],
+	}
+}
+
+func (lhs Vec3[T]) Div(rhs Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		lhs[0] / rhs[0],
+		lhs[1] / rhs[1],
+		lhs[2] / rhs[2],
+	}
+}
+
+func (lhs Vec3[T]) DivScalar(s T) Vec3[T] {
+	return Vec3[T]{
+		lhs[0] / s,
+		lhs[1] / s,
+		lhs[2] / s,
+	}
+}
+
+func (lhs Vec3[T]) Neg() Vec3[T] {
+	return Vec3[T]{-lhs[0], -lhs[1], -lhs[2]}
+}
+
+func (lhs Vec3[T]) Lerp(rhs Vec3[T], t T) Vec3[T] {
+	return lhs.Add(rhs.Sub(lhs).MulScalar(t))
+}
+
+func (lhs Vec3[T]) Min(rhs Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		minf(lhs[0], rhs[0]),
+		minf(lhs[1], rhs[1]),
+		minf(lhs[2], rhs[2]),
+	}
+}
+
+func (lhs Vec3[T]) Max(rhs Vec3[T]) Vec3[T] {
+	return Vec3[T]{
+		maxf(lhs[0], rhs[0]),
+		maxf(lhs[1], rhs[1]),
+		maxf(lhs[2], rhs[2]),
+	}
+}
+
+func (lhs Vec3[T]) Distance(rhs Vec3[T]) T {
+	return rhs.Sub(lhs).Magnitude()
+}
+
+func (lhs Vec3[T]) Truncate() Vec2[T] {
+	return Vec2[T]{lhs[0], lhs[1]}
+}
+
+func (lhs Vec3[T]) Extend(w T) Vec4[T] {
+	return Vec4[T]{lhs[0], lhs[1], lhs[2], w}
+}
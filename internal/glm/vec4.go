@@ -0,0 +1,105 @@
+package glm
+
+import "math"
+
+type Vec4[T float] [4]T
+
+func (lhs Vec4[T]) Dot(rhs Vec4[T]) T {
+	return (lhs[0] * rhs[0]) + (lhs[1] * rhs[1]) + (lhs[2] * rhs[2]) + (lhs[3] * rhs[3])
+}
+
+func (lhs Vec4[T]) Magnitude() T {
+	return T(math.Sqrt(float64(lhs.Dot(lhs))))
+}
+
+func (lhs Vec4[T]) MulScalar(s T) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] * s,
+		lhs[1] * s,
+		lhs[2] * s,
+		lhs[3] * s,
+	}
+}
+
+func (lhs Vec4[T]) DivScalar(s T) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] / s,
+		lhs[1] / s,
+		lhs[2] / s,
+		lhs[3] / s,
+	}
+}
+
+func (lhs Vec4[T]) Normalize() Vec4[T] {
+	return lhs.MulScalar(1 / lhs.Magnitude())
+}
+
+func (lhs Vec4[T]) Add(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] + rhs[0],
+		lhs[1] + rhs[1],
+		lhs[2] + rhs[2],
+		lhs[3] + rhs[3],
+	}
+}
+
+func (lhs Vec4[T]) Sub(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] - rhs[0],
+		lhs[1] - rhs[1],
+		lhs[2] - rhs[2],
+		lhs[3] - rhs[3],
+	}
+}
+
+func (lhs Vec4[T]) Mul(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] * rhs[0],
+		lhs[1] * rhs[1],
+		lhs[2] * rhs[2],
+		lhs[3] * rhs[3],
+	}
+}
+
+func (lhs Vec4[T]) Div(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		lhs[0] / rhs[0],
+		lhs[1] / rhs[1],
+		lhs[2] / rhs[2],
+		lhs[3] / rhs[3],
+	}
+}
+
+func (lhs Vec4[T]) Neg() Vec4[T] {
+	return Vec4[T]{-lhs[0], -lhs[1], -lhs[2], -lhs[3]}
+}
+
+func (lhs Vec4[T]) Lerp(rhs Vec4[T], t T) Vec4[T] {
+	return lhs.Add(rhs.Sub(lhs).MulScalar(t))
+}
+
+func (lhs Vec4[T]) Min(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		minf(lhs[0], rhs[0]),
+		minf(lhs[1], rhs[1]),
+		minf(lhs[2], rhs[2]),
+		minf(lhs[3], rhs[3]),
+	}
+}
+
+func (lhs Vec4[T]) Max(rhs Vec4[T]) Vec4[T] {
+	return Vec4[T]{
+		maxf(lhs[0], rhs[0]),
+		maxf(lhs[1], rhs[1]),
+		maxf(lhs[2], rhs[2]),
+		maxf(lhs[3], rhs[3]),
+	}
+}
+
+func (lhs Vec4[T]) Distance(rhs Vec4[T]) T {
+	return rhs.Sub(lhs).Magnitude()
+}
+
+func (lhs Vec4[T]) Truncate() Vec3[T] {
+	return Vec3[T]{lhs[0], lhs[1], lhs[2]}
+}
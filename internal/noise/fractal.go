@@ -0,0 +1,54 @@
+package noise
+
+// FBm2 sums octaves of f, each with its frequency multiplied by
+// lacunarity and its amplitude by gain. The result is normalized
+// by the total amplitude so it keeps the range of f.
+func FBm2(f Func2, x, y float32, octaves int, lacunarity, gain float32) float32 {
+	var sum, norm float32
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * f(x, y)
+		norm += amplitude
+		x *= lacunarity
+		y *= lacunarity
+		amplitude *= gain
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// FBm3 is the 3D version of FBm2.
+func FBm3(f Func3, x, y, z float32, octaves int, lacunarity, gain float32) float32 {
+	var sum, norm float32
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * f(x, y, z)
+		norm += amplitude
+		x *= lacunarity
+		y *= lacunarity
+		z *= lacunarity
+		amplitude *= gain
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// Warp2 samples f at a position displaced by f itself,
+// scaled by strength (domain warping).
+func Warp2(f Func2, x, y, strength float32) float32 {
+	qx := f(x, y)
+	qy := f(x+5.2, y+1.3)
+	return f(x+strength*qx, y+strength*qy)
+}
+
+// Warp3 is the 3D version of Warp2.
+func Warp3(f Func3, x, y, z, strength float32) float32 {
+	qx := f(x, y, z)
+	qy := f(x+5.2, y+1.3, z+2.8)
+	qz := f(x+1.7, y+9.2, z+4.6)
+	return f(x+strength*qx, y+strength*qy, z+strength*qz)
+}
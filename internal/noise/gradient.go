@@ -0,0 +1,138 @@
+package noise
+
+import "math"
+
+// Perlin2 returns 2D gradient noise in about [-1, 1].
+func (n *Noise) Perlin2(x, y float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	u, v := fade(fx), fade(fy)
+
+	n00 := grad2(n.hash2(ix, iy), fx, fy)
+	n10 := grad2(n.hash2(ix+1, iy), fx-1, fy)
+	n01 := grad2(n.hash2(ix, iy+1), fx, fy-1)
+	n11 := grad2(n.hash2(ix+1, iy+1), fx-1, fy-1)
+
+	return lerp(lerp(n00, n10, u), lerp(n01, n11, u), v)
+}
+
+// Perlin3 returns 3D gradient noise in about [-1, 1].
+func (n *Noise) Perlin3(x, y, z float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	u, v, w := fade(fx), fade(fy), fade(fz)
+
+	n000 := grad3(n.hash3(ix, iy, iz), fx, fy, fz)
+	n100 := grad3(n.hash3(ix+1, iy, iz), fx-1, fy, fz)
+	n010 := grad3(n.hash3(ix, iy+1, iz), fx, fy-1, fz)
+	n110 := grad3(n.hash3(ix+1, iy+1, iz), fx-1, fy-1, fz)
+	n001 := grad3(n.hash3(ix, iy, iz+1), fx, fy, fz-1)
+	n101 := grad3(n.hash3(ix+1, iy, iz+1), fx-1, fy, fz-1)
+	n011 := grad3(n.hash3(ix, iy+1, iz+1), fx, fy-1, fz-1)
+	n111 := grad3(n.hash3(ix+1, iy+1, iz+1), fx-1, fy-1, fz-1)
+
+	return lerp(
+		lerp(lerp(n000, n100, u), lerp(n010, n110, u), v),
+		lerp(lerp(n001, n101, u), lerp(n011, n111, u), v),
+		w,
+	)
+}
+
+var (
+	f2 = float32(0.5 * (math.Sqrt(3) - 1))
+	g2 = float32((3 - math.Sqrt(3)) / 6)
+)
+
+// Simplex2 returns 2D simplex noise in about [-1, 1].
+func (n *Noise) Simplex2(x, y float32) float32 {
+	s := (x + y) * f2
+	i, _ := floor(x + s)
+	j, _ := floor(y + s)
+	t := float32(i+j) * g2
+	x0 := x - (float32(i) - t)
+	y0 := y - (float32(j) - t)
+
+	var i1, j1 int32 = 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+
+	x1 := x0 - float32(i1) + g2
+	y1 := y0 - float32(j1) + g2
+	x2 := x0 - 1 + 2*g2
+	y2 := y0 - 1 + 2*g2
+
+	corner := func(h uint32, x, y float32) float32 {
+		t := 0.5 - x*x - y*y
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad2(h, x, y)
+	}
+
+	return 70 * (corner(n.hash2(i, j), x0, y0) +
+		corner(n.hash2(i+i1, j+j1), x1, y1) +
+		corner(n.hash2(i+1, j+1), x2, y2))
+}
+
+const (
+	f3 = 1.0 / 3.0
+	g3 = 1.0 / 6.0
+)
+
+// Simplex3 returns 3D simplex noise in about [-1, 1].
+func (n *Noise) Simplex3(x, y, z float32) float32 {
+	s := (x + y + z) * f3
+	i, _ := floor(x + s)
+	j, _ := floor(y + s)
+	k, _ := floor(z + s)
+	t := float32(i+j+k) * g3
+	x0 := x - (float32(i) - t)
+	y0 := y - (float32(j) - t)
+	z0 := z - (float32(k) - t)
+
+	var i1, j1, k1, i2, j2, k2 int32
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else {
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	x1 := x0 - float32(i1) + g3
+	y1 := y0 - float32(j1) + g3
+	z1 := z0 - float32(k1) + g3
+	x2 := x0 - float32(i2) + 2*g3
+	y2 := y0 - float32(j2) + 2*g3
+	z2 := z0 - float32(k2) + 2*g3
+	x3 := x0 - 1 + 3*g3
+	y3 := y0 - 1 + 3*g3
+	z3 := z0 - 1 + 3*g3
+
+	corner := func(h uint32, x, y, z float32) float32 {
+		t := 0.6 - x*x - y*y - z*z
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad3(h, x, y, z)
+	}
+
+	return 32 * (corner(n.hash3(i, j, k), x0, y0, z0) +
+		corner(n.hash3(i+i1, j+j1, k+k1), x1, y1, z1) +
+		corner(n.hash3(i+i2, j+j2, k+k2), x2, y2, z2) +
+		corner(n.hash3(i+1, j+1, k+1), x3, y3, z3))
+}
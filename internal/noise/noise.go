@@ -0,0 +1,109 @@
+// Package noise implements seedable procedural noise functions.
+//
+// All functions hash lattice coordinates with integer arithmetic
+// instead of a permutation table, so the WGSL versions produce the
+// same patterns on the GPU for the same seed.
+package noise
+
+import (
+	_ "embed"
+	"math"
+)
+
+// WGSL contains shader versions of the noise functions, prefixed with
+// noise_ and taking the seed as their last argument. It can be
+// prepended to shader source code.
+//
+//go:embed noise.wgsl
+var WGSL string
+
+// Func2 is a 2D noise function, e.g. Noise.Perlin2.
+type Func2 func(x, y float32) float32
+
+// Func3 is a 3D noise function, e.g. Noise.Perlin3.
+type Func3 func(x, y, z float32) float32
+
+type Noise struct {
+	seed uint32
+}
+
+func New(seed uint32) *Noise {
+	return &Noise{seed: hash(seed)}
+}
+
+// hash is the lowbias32 integer hash by Chris Wellons.
+func hash(x uint32) uint32 {
+	x ^= x >> 16
+	x *= 0x7feb352d
+	x ^= x >> 15
+	x *= 0x846ca68b
+	x ^= x >> 16
+	return x
+}
+
+func (n *Noise) hash2(x, y int32) uint32 {
+	return hash(uint32(x) ^ hash(uint32(y)^n.seed))
+}
+
+func (n *Noise) hash3(x, y, z int32) uint32 {
+	return hash(uint32(x) ^ hash(uint32(y)^hash(uint32(z)^n.seed)))
+}
+
+// unit maps a hash to [0, 1).
+func unit(h uint32) float32 {
+	return float32(h>>8) / (1 << 24)
+}
+
+func floor(x float32) (int32, float32) {
+	f := float32(math.Floor(float64(x)))
+	return int32(f), x - f
+}
+
+func fade(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+func grad2(h uint32, x, y float32) float32 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func grad3(h uint32, x, y, z float32) float32 {
+	h &= 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
@@ -0,0 +1,346 @@
+// Noise functions matching the Go implementation in
+// internal/noise, seed is the same value passed to noise.New.
+
+fn noise_hash(v: u32) -> u32 {
+    var x = v;
+    x ^= x >> 16u;
+    x *= 0x7feb352du;
+    x ^= x >> 15u;
+    x *= 0x846ca68bu;
+    x ^= x >> 16u;
+    return x;
+}
+
+fn noise_hash2(p: vec2<i32>, s: u32) -> u32 {
+    return noise_hash(bitcast<u32>(p.x) ^ noise_hash(bitcast<u32>(p.y) ^ s));
+}
+
+fn noise_hash3(p: vec3<i32>, s: u32) -> u32 {
+    return noise_hash(bitcast<u32>(p.x) ^ noise_hash(bitcast<u32>(p.y) ^ noise_hash(bitcast<u32>(p.z) ^ s)));
+}
+
+fn noise_unit(h: u32) -> f32 {
+    return f32(h >> 8u) / 16777216.0;
+}
+
+fn noise_fade(t: f32) -> f32 {
+    return t * t * t * (t * (t * 6.0 - 15.0) + 10.0);
+}
+
+fn noise_grad2(h: u32, x: f32, y: f32) -> f32 {
+    switch h & 7u {
+        case 0u: { return x + y; }
+        case 1u: { return -x + y; }
+        case 2u: { return x - y; }
+        case 3u: { return -x - y; }
+        case 4u: { return x; }
+        case 5u: { return -x; }
+        case 6u: { return y; }
+        default: { return -y; }
+    }
+}
+
+fn noise_grad3(hash: u32, x: f32, y: f32, z: f32) -> f32 {
+    let h = hash & 15u;
+    var u = y;
+    var v = z;
+    if h < 8u {
+        u = x;
+    }
+    if h < 4u {
+        v = y;
+    } else if h == 12u || h == 14u {
+        v = x;
+    }
+    if (h & 1u) != 0u {
+        u = -u;
+    }
+    if (h & 2u) != 0u {
+        v = -v;
+    }
+    return u + v;
+}
+
+fn noise_perlin2(p: vec2<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec2<i32>(floor(p));
+    let f = p - floor(p);
+    let u = noise_fade(f.x);
+    let v = noise_fade(f.y);
+
+    let n00 = noise_grad2(noise_hash2(i, s), f.x, f.y);
+    let n10 = noise_grad2(noise_hash2(i + vec2<i32>(1, 0), s), f.x - 1.0, f.y);
+    let n01 = noise_grad2(noise_hash2(i + vec2<i32>(0, 1), s), f.x, f.y - 1.0);
+    let n11 = noise_grad2(noise_hash2(i + vec2<i32>(1, 1), s), f.x - 1.0, f.y - 1.0);
+
+    return mix(mix(n00, n10, u), mix(n01, n11, u), v);
+}
+
+fn noise_perlin3(p: vec3<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec3<i32>(floor(p));
+    let f = p - floor(p);
+    let u = noise_fade(f.x);
+    let v = noise_fade(f.y);
+    let w = noise_fade(f.z);
+
+    let n000 = noise_grad3(noise_hash3(i, s), f.x, f.y, f.z);
+    let n100 = noise_grad3(noise_hash3(i + vec3<i32>(1, 0, 0), s), f.x - 1.0, f.y, f.z);
+    let n010 = noise_grad3(noise_hash3(i + vec3<i32>(0, 1, 0), s), f.x, f.y - 1.0, f.z);
+    let n110 = noise_grad3(noise_hash3(i + vec3<i32>(1, 1, 0), s), f.x - 1.0, f.y - 1.0, f.z);
+    let n001 = noise_grad3(noise_hash3(i + vec3<i32>(0, 0, 1), s), f.x, f.y, f.z - 1.0);
+    let n101 = noise_grad3(noise_hash3(i + vec3<i32>(1, 0, 1), s), f.x - 1.0, f.y, f.z - 1.0);
+    let n011 = noise_grad3(noise_hash3(i + vec3<i32>(0, 1, 1), s), f.x, f.y - 1.0, f.z - 1.0);
+    let n111 = noise_grad3(noise_hash3(i + vec3<i32>(1, 1, 1), s), f.x - 1.0, f.y - 1.0, f.z - 1.0);
+
+    return mix(
+        mix(mix(n000, n100, u), mix(n010, n110, u), v),
+        mix(mix(n001, n101, u), mix(n011, n111, u), v),
+        w,
+    );
+}
+
+fn noise_simplex2_corner(h: u32, p: vec2<f32>) -> f32 {
+    var t = 0.5 - dot(p, p);
+    if t < 0.0 {
+        return 0.0;
+    }
+    t *= t;
+    return t * t * noise_grad2(h, p.x, p.y);
+}
+
+fn noise_simplex2(p: vec2<f32>, seed: u32) -> f32 {
+    let F2 = 0.36602540378;
+    let G2 = 0.21132486540;
+
+    let s = noise_hash(seed);
+    let i = vec2<i32>(floor(p + (p.x + p.y) * F2));
+    let t = f32(i.x + i.y) * G2;
+    let p0 = p - (vec2<f32>(i) - t);
+
+    var i1 = vec2<i32>(0, 1);
+    if p0.x > p0.y {
+        i1 = vec2<i32>(1, 0);
+    }
+
+    let p1 = p0 - vec2<f32>(i1) + G2;
+    let p2 = p0 - 1.0 + 2.0 * G2;
+
+    return 70.0 * (noise_simplex2_corner(noise_hash2(i, s), p0) +
+        noise_simplex2_corner(noise_hash2(i + i1, s), p1) +
+        noise_simplex2_corner(noise_hash2(i + vec2<i32>(1, 1), s), p2));
+}
+
+fn noise_simplex3_corner(h: u32, p: vec3<f32>) -> f32 {
+    var t = 0.6 - dot(p, p);
+    if t < 0.0 {
+        return 0.0;
+    }
+    t *= t;
+    return t * t * noise_grad3(h, p.x, p.y, p.z);
+}
+
+fn noise_simplex3(p: vec3<f32>, seed: u32) -> f32 {
+    let F3 = 1.0 / 3.0;
+    let G3 = 1.0 / 6.0;
+
+    let s = noise_hash(seed);
+    let i = vec3<i32>(floor(p + (p.x + p.y + p.z) * F3));
+    let t = f32(i.x + i.y + i.z) * G3;
+    let p0 = p - (vec3<f32>(i) - t);
+
+    var i1: vec3<i32>;
+    var i2: vec3<i32>;
+    if p0.x >= p0.y {
+        if p0.y >= p0.z {
+            i1 = vec3<i32>(1, 0, 0);
+            i2 = vec3<i32>(1, 1, 0);
+        } else if p0.x >= p0.z {
+            i1 = vec3<i32>(1, 0, 0);
+            i2 = vec3<i32>(1, 0, 1);
+        } else {
+            i1 = vec3<i32>(0, 0, 1);
+            i2 = vec3<i32>(1, 0, 1);
+        }
+    } else {
+        if p0.y < p0.z {
+            i1 = vec3<i32>(0, 0, 1);
+            i2 = vec3<i32>(0, 1, 1);
+        } else if p0.x < p0.z {
+            i1 = vec3<i32>(0, 1, 0);
+            i2 = vec3<i32>(0, 1, 1);
+        } else {
+            i1 = vec3<i32>(0, 1, 0);
+            i2 = vec3<i32>(1, 1, 0);
+        }
+    }
+
+    let p1 = p0 - vec3<f32>(i1) + G3;
+    let p2 = p0 - vec3<f32>(i2) + 2.0 * G3;
+    let p3 = p0 - 1.0 + 3.0 * G3;
+
+    return 32.0 * (noise_simplex3_corner(noise_hash3(i, s), p0) +
+        noise_simplex3_corner(noise_hash3(i + i1, s), p1) +
+        noise_simplex3_corner(noise_hash3(i + i2, s), p2) +
+        noise_simplex3_corner(noise_hash3(i + vec3<i32>(1, 1, 1), s), p3));
+}
+
+fn noise_value2(p: vec2<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec2<i32>(floor(p));
+    let f = p - floor(p);
+    let u = noise_fade(f.x);
+    let v = noise_fade(f.y);
+
+    let n00 = noise_unit(noise_hash2(i, s));
+    let n10 = noise_unit(noise_hash2(i + vec2<i32>(1, 0), s));
+    let n01 = noise_unit(noise_hash2(i + vec2<i32>(0, 1), s));
+    let n11 = noise_unit(noise_hash2(i + vec2<i32>(1, 1), s));
+
+    return mix(mix(n00, n10, u), mix(n01, n11, u), v) * 2.0 - 1.0;
+}
+
+fn noise_value3(p: vec3<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec3<i32>(floor(p));
+    let f = p - floor(p);
+    let u = noise_fade(f.x);
+    let v = noise_fade(f.y);
+    let w = noise_fade(f.z);
+
+    let n000 = noise_unit(noise_hash3(i, s));
+    let n100 = noise_unit(noise_hash3(i + vec3<i32>(1, 0, 0), s));
+    let n010 = noise_unit(noise_hash3(i + vec3<i32>(0, 1, 0), s));
+    let n110 = noise_unit(noise_hash3(i + vec3<i32>(1, 1, 0), s));
+    let n001 = noise_unit(noise_hash3(i + vec3<i32>(0, 0, 1), s));
+    let n101 = noise_unit(noise_hash3(i + vec3<i32>(1, 0, 1), s));
+    let n011 = noise_unit(noise_hash3(i + vec3<i32>(0, 1, 1), s));
+    let n111 = noise_unit(noise_hash3(i + vec3<i32>(1, 1, 1), s));
+
+    return mix(
+        mix(mix(n000, n100, u), mix(n010, n110, u), v),
+        mix(mix(n001, n101, u), mix(n011, n111, u), v),
+        w,
+    ) * 2.0 - 1.0;
+}
+
+fn noise_worley2(p: vec2<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec2<i32>(floor(p));
+    let f = p - floor(p);
+
+    var min_dist = 3.40282347e+38;
+    for (var dy = -1; dy <= 1; dy++) {
+        for (var dx = -1; dx <= 1; dx++) {
+            let h = noise_hash2(i + vec2<i32>(dx, dy), s);
+            let d = vec2<f32>(f32(dx), f32(dy)) + vec2<f32>(noise_unit(h), noise_unit(noise_hash(h))) - f;
+            min_dist = min(min_dist, dot(d, d));
+        }
+    }
+    return sqrt(min_dist);
+}
+
+fn noise_worley3(p: vec3<f32>, seed: u32) -> f32 {
+    let s = noise_hash(seed);
+    let i = vec3<i32>(floor(p));
+    let f = p - floor(p);
+
+    var min_dist = 3.40282347e+38;
+    for (var dz = -1; dz <= 1; dz++) {
+        for (var dy = -1; dy <= 1; dy++) {
+            for (var dx = -1; dx <= 1; dx++) {
+                let h = noise_hash3(i + vec3<i32>(dx, dy, dz), s);
+                let h2 = noise_hash(h);
+                let d = vec3<f32>(f32(dx), f32(dy), f32(dz)) +
+                    vec3<f32>(noise_unit(h), noise_unit(h2), noise_unit(noise_hash(h2))) - f;
+                min_dist = min(min_dist, dot(d, d));
+            }
+        }
+    }
+    return sqrt(min_dist);
+}
+
+fn noise_fbm_perlin2(p: vec2<f32>, seed: u32, octaves: i32, lacunarity: f32, gain: f32) -> f32 {
+    var q = p;
+    var sum = 0.0;
+    var norm = 0.0;
+    var amplitude = 1.0;
+    for (var i = 0; i < octaves; i++) {
+        sum += amplitude * noise_perlin2(q, seed);
+        norm += amplitude;
+        q *= lacunarity;
+        amplitude *= gain;
+    }
+    if norm == 0.0 {
+        return 0.0;
+    }
+    return sum / norm;
+}
+
+fn noise_fbm_perlin3(p: vec3<f32>, seed: u32, octaves: i32, lacunarity: f32, gain: f32) -> f32 {
+    var q = p;
+    var sum = 0.0;
+    var norm = 0.0;
+    var amplitude = 1.0;
+    for (var i = 0; i < octaves; i++) {
+        sum += amplitude * noise_perlin3(q, seed);
+        norm += amplitude;
+        q *= lacunarity;
+        amplitude *= gain;
+    }
+    if norm == 0.0 {
+        return 0.0;
+    }
+    return sum / norm;
+}
+
+fn noise_fbm_simplex2(p: vec2<f32>, seed: u32, octaves: i32, lacunarity: f32, gain: f32) -> f32 {
+    var q = p;
+    var sum = 0.0;
+    var norm = 0.0;
+    var amplitude = 1.0;
+    for (var i = 0; i < octaves; i++) {
+        sum += amplitude * noise_simplex2(q, seed);
+        norm += amplitude;
+        q *= lacunarity;
+        amplitude *= gain;
+    }
+    if norm == 0.0 {
+        return 0.0;
+    }
+    return sum / norm;
+}
+
+fn noise_fbm_simplex3(p: vec3<f32>, seed: u32, octaves: i32, lacunarity: f32, gain: f32) -> f32 {
+    var q = p;
+    var sum = 0.0;
+    var norm = 0.0;
+    var amplitude = 1.0;
+    for (var i = 0; i < octaves; i++) {
+        sum += amplitude * noise_simplex3(q, seed);
+        norm += amplitude;
+        q *= lacunarity;
+        amplitude *= gain;
+    }
+    if norm == 0.0 {
+        return 0.0;
+    }
+    return sum / norm;
+}
+
+fn noise_warp_perlin2(p: vec2<f32>, seed: u32, strength: f32) -> f32 {
+    let q = vec2<f32>(
+        noise_perlin2(p, seed),
+        noise_perlin2(p + vec2<f32>(5.2, 1.3), seed),
+    );
+    return noise_perlin2(p + strength * q, seed);
+}
+
+fn noise_warp_perlin3(p: vec3<f32>, seed: u32, strength: f32) -> f32 {
+    let q = vec3<f32>(
+        noise_perlin3(p, seed),
+        noise_perlin3(p + vec3<f32>(5.2, 1.3, 2.8), seed),
+        noise_perlin3(p + vec3<f32>(1.7, 9.2, 4.6), seed),
+    );
+    return noise_perlin3(p + strength * q, seed);
+}
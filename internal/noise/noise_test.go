@@ -0,0 +1,210 @@
+package noise
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+const testSeed = 42
+
+// testFuncs are evaluated at every point by the compute shader in
+// TestWGSL, in this order.
+var testFuncs = []struct {
+	name string
+	wgsl string
+	eval func(n *Noise, x, y, z float32) float32
+}{
+	{"Perlin2", "noise_perlin2(p.xy, seed)", func(n *Noise, x, y, z float32) float32 { return n.Perlin2(x, y) }},
+	{"Perlin3", "noise_perlin3(p, seed)", func(n *Noise, x, y, z float32) float32 { return n.Perlin3(x, y, z) }},
+	{"Simplex2", "noise_simplex2(p.xy, seed)", func(n *Noise, x, y, z float32) float32 { return n.Simplex2(x, y) }},
+	{"Simplex3", "noise_simplex3(p, seed)", func(n *Noise, x, y, z float32) float32 { return n.Simplex3(x, y, z) }},
+	{"Value2", "noise_value2(p.xy, seed)", func(n *Noise, x, y, z float32) float32 { return n.Value2(x, y) }},
+	{"Value3", "noise_value3(p, seed)", func(n *Noise, x, y, z float32) float32 { return n.Value3(x, y, z) }},
+	{"Worley2", "noise_worley2(p.xy, seed)", func(n *Noise, x, y, z float32) float32 { return n.Worley2(x, y) }},
+	{"Worley3", "noise_worley3(p, seed)", func(n *Noise, x, y, z float32) float32 { return n.Worley3(x, y, z) }},
+	{"FBm2", "noise_fbm_perlin2(p.xy, seed, 5, 2.0, 0.5)", func(n *Noise, x, y, z float32) float32 {
+		return FBm2(n.Perlin2, x, y, 5, 2, 0.5)
+	}},
+	{"FBm3", "noise_fbm_simplex3(p, seed, 4, 2.0, 0.5)", func(n *Noise, x, y, z float32) float32 {
+		return FBm3(n.Simplex3, x, y, z, 4, 2, 0.5)
+	}},
+	{"Warp2", "noise_warp_perlin2(p.xy, seed, 0.8)", func(n *Noise, x, y, z float32) float32 {
+		return Warp2(n.Perlin2, x, y, 0.8)
+	}},
+	{"Warp3", "noise_warp_perlin3(p, seed, 0.8)", func(n *Noise, x, y, z float32) float32 {
+		return Warp3(n.Perlin3, x, y, z, 0.8)
+	}},
+}
+
+func testShader() string {
+	code := WGSL + fmt.Sprintf(`
+@group(0) @binding(0) var<storage, read> points: array<vec4<f32>>;
+@group(0) @binding(1) var<storage, read_write> results: array<f32>;
+
+@compute @workgroup_size(1)
+fn main(@builtin(global_invocation_id) id: vec3<u32>) {
+    let p = points[id.x].xyz;
+    let seed = %du;
+    let base = id.x * %du;
+`, testSeed, len(testFuncs))
+	for i, f := range testFuncs {
+		code += fmt.Sprintf("    results[base + %du] = %s;\n", i, f.wgsl)
+	}
+	return code + "}\n"
+}
+
+// TestWGSL evaluates noise.wgsl in a compute shader and compares it
+// with the Go functions. It is skipped if no adapter is available.
+func TestWGSL(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	points := make([][4]float32, 256)
+	for i := range points {
+		for j := 0; j < 3; j++ {
+			points[i][j] = rng.Float32()*40 - 20
+		}
+	}
+
+	results, err := runShader(testShader(), points, len(points)*len(testFuncs))
+	if err != nil {
+		t.Skip("no WebGPU device:", err)
+	}
+
+	n := New(testSeed)
+	for i, p := range points {
+		for j, f := range testFuncs {
+			want := f.eval(n, p[0], p[1], p[2])
+			got := results[i*len(testFuncs)+j]
+			if math.Abs(float64(got-want)) > 1e-4 {
+				t.Errorf("%s(%v, %v, %v): WGSL %v, Go %v", f.name, p[0], p[1], p[2], got, want)
+			}
+		}
+	}
+}
+
+// runShader dispatches the main entry point of code once per point and
+// reads back numResults floats from its results buffer.
+func runShader(code string, points [][4]float32, numResults int) ([]float32, error) {
+	instance := wgpu.CreateInstance(nil)
+	defer instance.Release()
+
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer adapter.Release()
+
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer device.Release()
+	queue := device.GetQueue()
+	defer queue.Release()
+
+	shader, err := device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+		Label:          "noise_test.wgsl",
+		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: code},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer shader.Release()
+
+	pointBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    "Point Buffer",
+		Contents: wgpu.ToBytes(points),
+		Usage:    wgpu.BufferUsage_Storage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer pointBuffer.Release()
+
+	size := uint64(numResults) * 4
+	resultBuffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Result Buffer",
+		Size:  size,
+		Usage: wgpu.BufferUsage_Storage | wgpu.BufferUsage_CopySrc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resultBuffer.Release()
+
+	stagingBuffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Size:  size,
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer stagingBuffer.Release()
+
+	pipeline, err := device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
+		Compute: wgpu.ProgrammableStageDescriptor{
+			Module:     shader,
+			EntryPoint: "main",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer pipeline.Release()
+
+	bindGroupLayout := pipeline.GetBindGroupLayout(0)
+	defer bindGroupLayout.Release()
+
+	bindGroup, err := device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Layout: bindGroupLayout,
+		Entries: []wgpu.BindGroupEntry{
+			{Binding: 0, Buffer: pointBuffer, Size: wgpu.WholeSize},
+			{Binding: 1, Buffer: resultBuffer, Size: wgpu.WholeSize},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer bindGroup.Release()
+
+	encoder, err := device.CreateCommandEncoder(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Release()
+
+	computePass := encoder.BeginComputePass(nil)
+	defer computePass.Release()
+	computePass.SetPipeline(pipeline)
+	computePass.SetBindGroup(0, bindGroup, nil)
+	computePass.DispatchWorkgroups(uint32(len(points)), 1, 1)
+	computePass.End()
+
+	encoder.CopyBufferToBuffer(resultBuffer, 0, stagingBuffer, 0, size)
+
+	cmdBuffer, err := encoder.Finish(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cmdBuffer.Release()
+	queue.Submit(cmdBuffer)
+
+	var status wgpu.BufferMapAsyncStatus
+	err = stagingBuffer.MapAsync(wgpu.MapMode_Read, 0, size, func(s wgpu.BufferMapAsyncStatus) {
+		status = s
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer stagingBuffer.Unmap()
+
+	device.Poll(true, nil)
+	if status != wgpu.BufferMapAsyncStatus_Success {
+		return nil, fmt.Errorf("mapping results: %v", status)
+	}
+
+	return append([]float32(nil), wgpu.FromBytes[float32](stagingBuffer.GetMappedRange(0, uint(size)))...), nil
+}
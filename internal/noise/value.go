@@ -0,0 +1,38 @@
+package noise
+
+// Value2 returns 2D value noise in [-1, 1].
+func (n *Noise) Value2(x, y float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	u, v := fade(fx), fade(fy)
+
+	n00 := unit(n.hash2(ix, iy))
+	n10 := unit(n.hash2(ix+1, iy))
+	n01 := unit(n.hash2(ix, iy+1))
+	n11 := unit(n.hash2(ix+1, iy+1))
+
+	return lerp(lerp(n00, n10, u), lerp(n01, n11, u), v)*2 - 1
+}
+
+// Value3 returns 3D value noise in [-1, 1].
+func (n *Noise) Value3(x, y, z float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	u, v, w := fade(fx), fade(fy), fade(fz)
+
+	n000 := unit(n.hash3(ix, iy, iz))
+	n100 := unit(n.hash3(ix+1, iy, iz))
+	n010 := unit(n.hash3(ix, iy+1, iz))
+	n110 := unit(n.hash3(ix+1, iy+1, iz))
+	n001 := unit(n.hash3(ix, iy, iz+1))
+	n101 := unit(n.hash3(ix+1, iy, iz+1))
+	n011 := unit(n.hash3(ix, iy+1, iz+1))
+	n111 := unit(n.hash3(ix+1, iy+1, iz+1))
+
+	return lerp(
+		lerp(lerp(n000, n100, u), lerp(n010, n110, u), v),
+		lerp(lerp(n001, n101, u), lerp(n011, n111, u), v),
+		w,
+	)*2 - 1
+}
@@ -0,0 +1,49 @@
+package noise
+
+import "math"
+
+// Worley2 returns the distance to the nearest feature point of 2D
+// cellular noise, one point is placed randomly in every unit cell.
+func (n *Noise) Worley2(x, y float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+
+	minDist := float32(math.MaxFloat32)
+	for dy := int32(-1); dy <= 1; dy++ {
+		for dx := int32(-1); dx <= 1; dx++ {
+			h := n.hash2(ix+dx, iy+dy)
+			px := float32(dx) + unit(h) - fx
+			py := float32(dy) + unit(hash(h)) - fy
+
+			if d := px*px + py*py; d < minDist {
+				minDist = d
+			}
+		}
+	}
+	return float32(math.Sqrt(float64(minDist)))
+}
+
+// Worley3 is the 3D version of Worley2.
+func (n *Noise) Worley3(x, y, z float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+
+	minDist := float32(math.MaxFloat32)
+	for dz := int32(-1); dz <= 1; dz++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dx := int32(-1); dx <= 1; dx++ {
+				h := n.hash3(ix+dx, iy+dy, iz+dz)
+				h2 := hash(h)
+				px := float32(dx) + unit(h) - fx
+				py := float32(dy) + unit(h2) - fy
+				pz := float32(dz) + unit(hash(h2)) - fz
+
+				if d := px*px + py*py + pz*pz; d < minDist {
+					minDist = d
+				}
+			}
+		}
+	}
+	return float32(math.Sqrt(float64(minDist)))
+}
@@ -0,0 +1,144 @@
+package scene
+
+import (
+	"errors"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+// ErrCycle is returned by AddChild when the child is the parent itself
+// or one of its ancestors.
+var ErrCycle = errors.New("scene: child is an ancestor of its parent")
+
+// Node is a transform in a hierarchy. Its local matrix is built from
+// translation, rotation and scale, and its world matrix is the parent's
+// world matrix times the local one. Both are computed lazily and cached
+// until the node or one of its ancestors changes.
+type Node[T float32 | float64] struct {
+	Name string
+
+	translation glm.Vec3[T]
+	rotation    glm.Quaternion[T]
+	scale       glm.Vec3[T]
+
+	parent   *Node[T]
+	children []*Node[T]
+
+	local      glm.Mat4[T]
+	world      glm.Mat4[T]
+	localDirty bool
+	worldDirty bool
+}
+
+func NewNode[T float32 | float64](name string) *Node[T] {
+	return &Node[T]{
+		Name:       name,
+		rotation:   glm.QuaternionIdentity[T](),
+		scale:      glm.Vec3[T]{1, 1, 1},
+		localDirty: true,
+		worldDirty: true,
+	}
+}
+
+func (n *Node[T]) Translation() glm.Vec3[T]    { return n.translation }
+func (n *Node[T]) Rotation() glm.Quaternion[T] { return n.rotation }
+func (n *Node[T]) Scale() glm.Vec3[T]          { return n.scale }
+func (n *Node[T]) Parent() *Node[T]            { return n.parent }
+func (n *Node[T]) Children() []*Node[T]        { return n.children }
+
+func (n *Node[T]) SetTranslation(v glm.Vec3[T]) {
+	n.translation = v
+	n.markLocalDirty()
+}
+
+func (n *Node[T]) SetRotation(q glm.Quaternion[T]) {
+	n.rotation = q
+	n.markLocalDirty()
+}
+
+func (n *Node[T]) SetScale(v glm.Vec3[T]) {
+	n.scale = v
+	n.markLocalDirty()
+}
+
+// SetLocalMatrix decomposes m into translation, rotation and scale,
+// m must not contain shear.
+func (n *Node[T]) SetLocalMatrix(m glm.Mat4[T]) {
+	n.translation, n.rotation, n.scale = m.Decompose()
+	n.markLocalDirty()
+}
+
+// AddChild attaches c to n, detaching it from its previous parent.
+// It returns ErrCycle, leaving the hierarchy unchanged, if c is n or
+// one of its ancestors.
+func (n *Node[T]) AddChild(c *Node[T]) error {
+	for p := n; p != nil; p = p.parent {
+		if p == c {
+			return ErrCycle
+		}
+	}
+
+	if c.parent != nil {
+		c.parent.RemoveChild(c)
+	}
+	c.parent = n
+	n.children = append(n.children, c)
+	c.markWorldDirty()
+	return nil
+}
+
+func (n *Node[T]) RemoveChild(c *Node[T]) {
+	for i, child := range n.children {
+		if child == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			c.parent = nil
+			c.markWorldDirty()
+			return
+		}
+	}
+}
+
+func (n *Node[T]) LocalMatrix() glm.Mat4[T] {
+	if n.localDirty {
+		n.local = glm.Mat4FromTRS(n.translation, n.rotation, n.scale)
+		n.localDirty = false
+	}
+	return n.local
+}
+
+func (n *Node[T]) WorldMatrix() glm.Mat4[T] {
+	if n.worldDirty {
+		if n.parent != nil {
+			n.world = n.parent.WorldMatrix().Mul4(n.LocalMatrix())
+		} else {
+			n.world = n.LocalMatrix()
+		}
+		n.worldDirty = false
+	}
+	return n.world
+}
+
+// Walk calls fn for n and all of its descendants, depth first.
+func (n *Node[T]) Walk(fn func(*Node[T])) {
+	fn(n)
+	for _, c := range n.children {
+		c.Walk(fn)
+	}
+}
+
+func (n *Node[T]) markLocalDirty() {
+	n.localDirty = true
+	n.markWorldDirty()
+}
+
+func (n *Node[T]) markWorldDirty() {
+	// a node's world matrix can only be clean if all of its
+	// ancestors are clean, so a dirty node has dirty descendants
+	if n.worldDirty {
+		return
+	}
+	n.worldDirty = true
+	for _, c := range n.children {
+		c.markWorldDirty()
+	}
+}
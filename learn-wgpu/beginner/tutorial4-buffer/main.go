@@ -8,6 +8,7 @@ import (
 
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/gamen/dpi"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -61,6 +62,9 @@ var VERTICES = [...]Vertex{
 
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
+// ClearColor is sRGB encoded like the vertex colours above.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface        *wgpu.Surface
 	swapChain      *wgpu.SwapChain
@@ -73,6 +77,7 @@ type State struct {
 	vertexBuffer *wgpu.Buffer
 	indexBuffer  *wgpu.Buffer
 	numIndices   uint32
+	clearColor   wgpu.Color
 }
 
 func InitState(window display.Window) (s *State, err error) {
@@ -168,9 +173,16 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	vertices := VERTICES
+	for i, v := range vertices {
+		c := glm.Color[float32]{R: v.color[0], G: v.color[1], B: v.color[2]}.ForFormat(s.config.Format)
+		vertices[i].color = c.Vec3()
+	}
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	s.vertexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
-		Contents: wgpu.ToBytes(VERTICES[:]),
+		Contents: wgpu.ToBytes(vertices[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
@@ -222,15 +234,10 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 	})
 	defer renderPass.Release()
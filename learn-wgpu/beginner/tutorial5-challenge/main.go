@@ -9,6 +9,7 @@ import (
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/gamen/dpi"
 	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -68,12 +69,16 @@ var VERTICES = [...]Vertex{
 
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface          *wgpu.Surface
 	swapChain        *wgpu.SwapChain
 	device           *wgpu.Device
 	queue            *wgpu.Queue
 	config           *wgpu.SwapChainDescriptor
+	clearColor       wgpu.Color
 	size             dpi.PhysicalSize[uint32]
 	renderPipeline   *wgpu.RenderPipeline
 	vertexBuffer     *wgpu.Buffer
@@ -129,6 +134,8 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	textureBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
 		Entries: []wgpu.BindGroupLayoutEntry{
 			{
@@ -309,15 +316,10 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 	})
 	defer renderPass.Release()
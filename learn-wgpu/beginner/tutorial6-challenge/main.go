@@ -4,11 +4,13 @@ import (
 	_ "embed"
 	"fmt"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/gamen/dpi"
 	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/animation"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
@@ -66,13 +68,6 @@ var VERTICES = [...]Vertex{
 
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
-var OpenGlToWgpuMatrix = glm.Mat4[float32]{
-	1.0, 0.0, 0.0, 0.0,
-	0.0, 1.0, 0.0, 0.0,
-	0.0, 0.0, 0.5, 0.0,
-	0.0, 0.0, 0.5, 1.0,
-}
-
 type Camera struct {
 	eye     glm.Vec3[float32]
 	target  glm.Vec3[float32]
@@ -85,25 +80,34 @@ type Camera struct {
 
 func (c *Camera) buildViewProjectionMatrix() glm.Mat4[float32] {
 	view := glm.LookAtRH(c.eye, c.target, c.up)
-	proj := glm.Perspective(c.fovYRad, c.aspect, c.znear, c.zfar)
+	proj := glm.PerspectiveRH(c.fovYRad, c.aspect, c.znear, c.zfar)
 	return proj.Mul4(view)
 }
 
 type CameraStaging struct {
 	camera           *Camera
 	modelRotationDeg float32
+	modelRotation    *animation.Track[float32, float32]
+	startTime        time.Time
 }
 
 func NewCameraStaging(camera *Camera) *CameraStaging {
+	modelRotation := animation.NewScalarTrack([]animation.Keyframe[float32, float32]{
+		{Time: 0, Value: 0},
+		{Time: 3, Value: 360},
+	}, animation.InterpolationLinear)
+	modelRotation.Mode = animation.ModeLoop
+
 	return &CameraStaging{
 		camera:           camera,
 		modelRotationDeg: 0,
+		modelRotation:    modelRotation,
+		startTime:        time.Now(),
 	}
 }
 
 func (c *CameraStaging) UpdateCamera(cameraUniform *CameraUniform) {
-	cameraUniform.modelViewProj = OpenGlToWgpuMatrix.
-		Mul4(c.camera.buildViewProjectionMatrix()).
+	cameraUniform.modelViewProj = c.camera.buildViewProjectionMatrix().
 		Mul4(glm.Mat4FromAngleZ(glm.DegToRad(c.modelRotationDeg)))
 }
 
@@ -159,12 +163,16 @@ func (c *CameraController) UpdateCamera(camera *Camera) {
 	}
 }
 
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface          *wgpu.Surface
 	swapChain        *wgpu.SwapChain
 	device           *wgpu.Device
 	queue            *wgpu.Queue
 	config           *wgpu.SwapChainDescriptor
+	clearColor       wgpu.Color
 	size             dpi.PhysicalSize[uint32]
 	renderPipeline   *wgpu.RenderPipeline
 	vertexBuffer     *wgpu.Buffer
@@ -222,6 +230,8 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
 	if err != nil {
 		return s, err
@@ -401,7 +411,9 @@ func InitState(window display.Window) (s *State, err error) {
 
 func (s *State) Update() {
 	s.cameraController.UpdateCamera(s.cameraStaging.camera)
-	s.cameraStaging.modelRotationDeg += 2
+	s.cameraStaging.modelRotationDeg = s.cameraStaging.modelRotation.Sample(
+		float32(time.Since(s.cameraStaging.startTime).Seconds()),
+	)
 	s.cameraStaging.UpdateCamera(s.cameraUniform)
 	s.queue.WriteBuffer(s.cameraBuffer, 0, wgpu.ToBytes(s.cameraUniform.modelViewProj[:]))
 }
@@ -440,15 +452,10 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 	})
 	defer renderPass.Release()
@@ -76,13 +76,6 @@ var VERTICES = [...]Vertex{
 
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
-var OpenGlToWgpuMatrix = glm.Mat4[float32]{
-	1.0, 0.0, 0.0, 0.0,
-	0.0, 1.0, 0.0, 0.0,
-	0.0, 0.0, 0.5, 0.0,
-	0.0, 0.0, 0.5, 1.0,
-}
-
 type Camera struct {
 	eye     glm.Vec3[float32]
 	target  glm.Vec3[float32]
@@ -95,7 +88,7 @@ type Camera struct {
 
 func (c *Camera) buildViewProjectionMatrix() glm.Mat4[float32] {
 	view := glm.LookAtRH(c.eye, c.target, c.up)
-	proj := glm.Perspective(c.fovYRad, c.aspect, c.znear, c.zfar)
+	proj := glm.PerspectiveRH(c.fovYRad, c.aspect, c.znear, c.zfar)
 	return proj.Mul4(view)
 }
 
@@ -115,7 +108,7 @@ func NewCameraUnifrom() *CameraUniform {
 }
 
 func (c *CameraUniform) UpdateViewProj(camera *Camera) {
-	c.viewProj = OpenGlToWgpuMatrix.Mul4(camera.buildViewProjectionMatrix())
+	c.viewProj = camera.buildViewProjectionMatrix()
 }
 
 type CameraController struct {
@@ -197,12 +190,16 @@ var InstanceBufferLayout = wgpu.VertexBufferLayout{
 	},
 }
 
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface          *wgpu.Surface
 	swapChain        *wgpu.SwapChain
 	device           *wgpu.Device
 	queue            *wgpu.Queue
 	config           *wgpu.SwapChainDescriptor
+	clearColor       wgpu.Color
 	size             dpi.PhysicalSize[uint32]
 	renderPipeline   *wgpu.RenderPipeline
 	vertexBuffer     *wgpu.Buffer
@@ -262,6 +259,8 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
 	if err != nil {
 		return s, err
@@ -532,15 +531,10 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 	})
 	defer renderPass.Release()
@@ -4,11 +4,13 @@ import (
 	_ "embed"
 	"fmt"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/gamen/dpi"
 	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/animation"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
@@ -74,13 +76,6 @@ var VERTICES = [...]Vertex{
 
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
-var OpenGlToWgpuMatrix = glm.Mat4[float32]{
-	1.0, 0.0, 0.0, 0.0,
-	0.0, 1.0, 0.0, 0.0,
-	0.0, 0.0, 0.5, 0.0,
-	0.0, 0.0, 0.5, 1.0,
-}
-
 type Camera struct {
 	eye     glm.Vec3[float32]
 	target  glm.Vec3[float32]
@@ -93,7 +88,7 @@ type Camera struct {
 
 func (c *Camera) buildViewProjectionMatrix() glm.Mat4[float32] {
 	view := glm.LookAtRH(c.eye, c.target, c.up)
-	proj := glm.Perspective(c.fovYRad, c.aspect, c.znear, c.zfar)
+	proj := glm.PerspectiveRH(c.fovYRad, c.aspect, c.znear, c.zfar)
 	return proj.Mul4(view)
 }
 
@@ -113,7 +108,7 @@ func NewCameraUnifrom() *CameraUniform {
 }
 
 func (c *CameraUniform) UpdateViewProj(camera *Camera) {
-	c.viewProj = OpenGlToWgpuMatrix.Mul4(camera.buildViewProjectionMatrix())
+	c.viewProj = camera.buildViewProjectionMatrix()
 }
 
 type CameraController struct {
@@ -153,16 +148,63 @@ func (c *CameraController) UpdateCamera(camera *Camera) {
 type Instance struct {
 	position glm.Vec3[float32]
 	rotation glm.Quaternion[float32]
+
+	positionTrack *animation.Track[float32, glm.Vec3[float32]]
+	rotationTrack *animation.Track[float32, glm.Quaternion[float32]]
+	// phase offsets the time the tracks are sampled at
+	phase float32
+}
+
+// NewInstance creates an instance bobbing up and down above position
+// while spinning around its rotated up axis.
+func NewInstance(position glm.Vec3[float32], rotation glm.Quaternion[float32], phase float32) Instance {
+	positionTrack := animation.NewVec3Track([]animation.Keyframe[float32, glm.Vec3[float32]]{
+		{Time: 0, Value: position},
+		{Time: 1, Value: position.Add(glm.Vec3[float32]{0, 0.5, 0})},
+	}, animation.InterpolationLinear)
+	positionTrack.Mode = animation.ModePingPong
+	positionTrack.Easing = animation.EaseInOutSine[float32]
+
+	// a full turn is split in thirds, slerp takes the shortest arc
+	// between keyframes
+	spin := make([]animation.Keyframe[float32, glm.Quaternion[float32]], 4)
+	for i := range spin {
+		angle := glm.DegToRad(120 * float32(i))
+		spin[i] = animation.Keyframe[float32, glm.Quaternion[float32]]{
+			Time:  2 * float32(i),
+			Value: rotation.Mul(glm.QuaternionFromAxisAngle(glm.Vec3[float32]{0, 1, 0}, angle)),
+		}
+	}
+	rotationTrack := animation.NewQuaternionTrack(spin, animation.InterpolationLinear)
+	rotationTrack.Mode = animation.ModeLoop
+
+	return Instance{
+		position:      position,
+		rotation:      rotation,
+		positionTrack: positionTrack,
+		rotationTrack: rotationTrack,
+		phase:         phase,
+	}
+}
+
+// Animate sets the position and rotation of the instance to the
+// values of its tracks at time t, in seconds.
+func (i *Instance) Animate(t float32) {
+	i.position = i.positionTrack.Sample(t + i.phase)
+	i.rotation = i.rotationTrack.Sample(t + i.phase)
 }
 
 func (i Instance) ToRaw() InstanceRaw {
+	model := glm.Mat4FromTranslation(i.position).Mul4(glm.Mat4FromQuaternion(i.rotation))
 	return InstanceRaw{
-		model: glm.Mat4FromTranslation(i.position).Mul4(glm.Mat4FromQuaternion(i.rotation)),
+		model:  model,
+		normal: model.NormalMatrix(),
 	}
 }
 
 type InstanceRaw struct {
-	model glm.Mat4[float32]
+	model  glm.Mat4[float32]
+	normal glm.Mat3[float32]
 }
 
 var InstanceBufferLayout = wgpu.VertexBufferLayout{
@@ -189,15 +231,34 @@ var InstanceBufferLayout = wgpu.VertexBufferLayout{
 			ShaderLocation: 8,
 			Format:         wgpu.VertexFormat_Float32x4,
 		},
+		{
+			Offset:         uint64(unsafe.Sizeof([16]float32{})),
+			ShaderLocation: 9,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
+		{
+			Offset:         uint64(unsafe.Sizeof([19]float32{})),
+			ShaderLocation: 10,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
+		{
+			Offset:         uint64(unsafe.Sizeof([22]float32{})),
+			ShaderLocation: 11,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
 	},
 }
 
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface          *wgpu.Surface
 	swapChain        *wgpu.SwapChain
 	device           *wgpu.Device
 	queue            *wgpu.Queue
 	config           *wgpu.SwapChainDescriptor
+	clearColor       wgpu.Color
 	size             dpi.PhysicalSize[uint32]
 	renderPipeline   *wgpu.RenderPipeline
 	vertexBuffer     *wgpu.Buffer
@@ -211,8 +272,9 @@ type State struct {
 	cameraBuffer     *wgpu.Buffer
 	cameraBindGroup  *wgpu.BindGroup
 
-	numInstances   uint32
+	instances      [NumInstancesPerRow * NumInstancesPerRow]Instance
 	instanceBuffer *wgpu.Buffer
+	startTime      time.Time
 }
 
 func InitState(window display.Window) (s *State, err error) {
@@ -257,6 +319,8 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
 	if err != nil {
 		return s, err
@@ -328,8 +392,6 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
-	var instances [NumInstancesPerRow * NumInstancesPerRow]Instance
-	s.numInstances = uint32(len(instances))
 	{
 		index := 0
 		for z := 0; z < NumInstancesPerRow; z++ {
@@ -343,27 +405,25 @@ func InitState(window display.Window) (s *State, err error) {
 					rotation = glm.QuaternionFromAxisAngle(position.Normalize(), glm.DegToRad[float32](45))
 				}
 
-				instances[index] = Instance{
-					position: position,
-					rotation: rotation,
-				}
+				s.instances[index] = NewInstance(position, rotation, 0.1*float32(x+z))
 				index++
 			}
 		}
 	}
 
 	var instanceData [NumInstancesPerRow * NumInstancesPerRow]InstanceRaw
-	for i, v := range instances {
+	for i, v := range s.instances {
 		instanceData[i] = v.ToRaw()
 	}
 	s.instanceBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Instance Buffer",
 		Contents: wgpu.ToBytes(instanceData[:]),
-		Usage:    wgpu.BufferUsage_Vertex,
+		Usage:    wgpu.BufferUsage_Vertex | wgpu.BufferUsage_CopyDst,
 	})
 	if err != nil {
 		return s, err
 	}
+	s.startTime = time.Now()
 
 	cameraBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
 		Label: "CameraBindGroupLayout",
@@ -474,6 +534,14 @@ func (s *State) Update() {
 	s.cameraController.UpdateCamera(s.camera)
 	s.cameraUniform.UpdateViewProj(s.camera)
 	s.queue.WriteBuffer(s.cameraBuffer, 0, wgpu.ToBytes(s.cameraUniform.viewProj[:]))
+
+	t := float32(time.Since(s.startTime).Seconds())
+	var instanceData [NumInstancesPerRow * NumInstancesPerRow]InstanceRaw
+	for i := range s.instances {
+		s.instances[i].Animate(t)
+		instanceData[i] = s.instances[i].ToRaw()
+	}
+	s.queue.WriteBuffer(s.instanceBuffer, 0, wgpu.ToBytes(instanceData[:]))
 }
 
 func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
@@ -510,15 +578,10 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 	})
 	defer renderPass.Release()
@@ -529,7 +592,7 @@ func (s *State) Render() error {
 	renderPass.SetVertexBuffer(0, s.vertexBuffer, 0, wgpu.WholeSize)
 	renderPass.SetVertexBuffer(1, s.instanceBuffer, 0, wgpu.WholeSize)
 	renderPass.SetIndexBuffer(s.indexBuffer, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
-	renderPass.DrawIndexed(s.numIndices, s.numInstances, 0, 0, 0)
+	renderPass.DrawIndexed(s.numIndices, uint32(len(s.instances)), 0, 0, 0)
 	renderPass.End()
 
 	cmdBuffer, err := encoder.Finish(nil)
@@ -0,0 +1,305 @@
+package gltfloader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Accessor component types.
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+func componentSize(componentType int) int {
+	switch componentType {
+	case componentByte, componentUnsignedByte:
+		return 1
+	case componentShort, componentUnsignedShort:
+		return 2
+	case componentUnsignedInt, componentFloat:
+		return 4
+	}
+	return 0
+}
+
+// typeSize returns the number of components of an accessor type and
+// the number of rows of its matrix columns, which are padded to 4
+// bytes.
+func typeSize(typ string) (components, rows int) {
+	switch typ {
+	case "SCALAR":
+		return 1, 1
+	case "VEC2":
+		return 2, 2
+	case "VEC3":
+		return 3, 3
+	case "VEC4":
+		return 4, 4
+	case "MAT2":
+		return 4, 2
+	case "MAT3":
+		return 9, 3
+	case "MAT4":
+		return 16, 4
+	}
+	return 0, 0
+}
+
+// elementLayout returns the byte offsets of the components of an
+// element and its packed size.
+func elementLayout(a gltfAccessor) ([]int, int, error) {
+	size := componentSize(a.ComponentType)
+	if size == 0 {
+		return nil, 0, fmt.Errorf("unknown component type %d", a.ComponentType)
+	}
+	components, rows := typeSize(a.Type)
+	if components == 0 {
+		return nil, 0, fmt.Errorf("unknown type %q", a.Type)
+	}
+	if a.Count < 0 {
+		return nil, 0, errors.New("negative count")
+	}
+
+	offsets := make([]int, components)
+	if components == rows {
+		for i := range offsets {
+			offsets[i] = i * size
+		}
+		return offsets, components * size, nil
+	}
+
+	// every matrix column starts at a 4-byte boundary
+	columnSize := (rows*size + 3) &^ 3
+	for i := range offsets {
+		offsets[i] = i/rows*columnSize + i%rows*size
+	}
+	return offsets, components / rows * columnSize, nil
+}
+
+// readComponent converts the component at the start of b to float32,
+// normalizing integers to [0, 1] or [-1, 1] if normalized is set.
+func readComponent(b []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case componentByte:
+		c := float32(int8(b[0]))
+		if normalized {
+			return float32(math.Max(float64(c/127), -1))
+		}
+		return c
+	case componentUnsignedByte:
+		c := float32(b[0])
+		if normalized {
+			return c / 255
+		}
+		return c
+	case componentShort:
+		c := float32(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return float32(math.Max(float64(c/32767), -1))
+		}
+		return c
+	case componentUnsignedShort:
+		c := float32(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return c / 65535
+		}
+		return c
+	case componentUnsignedInt:
+		c := float32(binary.LittleEndian.Uint32(b))
+		if normalized {
+			return c / math.MaxUint32
+		}
+		return c
+	case componentFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+// elements returns the data of count elements of elementSize bytes
+// from buffer view, starting at offset, and their stride.
+func (l *loader) elements(view, offset, count, elementSize int) ([]byte, int, error) {
+	data, stride, err := l.bufferView(view)
+	if err != nil {
+		return nil, 0, err
+	}
+	if stride == 0 {
+		stride = elementSize
+	} else if stride < elementSize {
+		return nil, 0, fmt.Errorf("byte stride %d of buffer view %d is smaller than the element size %d", stride, view, elementSize)
+	}
+	if offset < 0 || count < 0 {
+		return nil, 0, errors.New("negative offset or count")
+	}
+	if offset > len(data) {
+		return nil, 0, fmt.Errorf("accessor exceeds buffer view %d", view)
+	}
+	if count > 0 {
+		// written to not overflow for huge counts
+		if last := len(data) - offset - elementSize; last < 0 || count-1 > last/stride {
+			return nil, 0, fmt.Errorf("accessor exceeds buffer view %d", view)
+		}
+	}
+	return data[offset:], stride, nil
+}
+
+// maxElements is the largest count of elementSize bytes of an
+// accessor without a buffer view. Its elements aren't backed by data,
+// so they are bounded by the size of all buffers to keep a small file
+// from requesting a huge allocation.
+func (l *loader) maxElements(elementSize int) int {
+	total := 0
+	for _, b := range l.buffers {
+		total += len(b)
+	}
+	return total / elementSize
+}
+
+// readFloats returns the elements of accessor i as float32 components,
+// n per element. Sparse values replace the ones in the buffer view, an
+// accessor without one is initialized with zeros.
+func (l *loader) readFloats(i int) ([]float32, int, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d out of range", i)
+	}
+	a := l.doc.Accessors[i]
+	offsets, elementSize, err := elementLayout(a)
+	if err != nil {
+		return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+	}
+	n := len(offsets)
+
+	var data []byte
+	var stride int
+	if a.BufferView != nil {
+		data, stride, err = l.elements(*a.BufferView, a.ByteOffset, a.Count, elementSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	} else if a.Count > l.maxElements(elementSize) {
+		return nil, 0, fmt.Errorf("accessor %d: count %d exceeds the size of the buffers", i, a.Count)
+	}
+
+	out := make([]float32, a.Count*n)
+	read := func(dst []float32, data []byte, stride, count int) {
+		for e := 0; e < count; e++ {
+			element := data[e*stride:]
+			for c, offset := range offsets {
+				dst[e*n+c] = readComponent(element[offset:], a.ComponentType, a.Normalized)
+			}
+		}
+	}
+	if a.BufferView != nil {
+		read(out, data, stride, a.Count)
+	}
+
+	if s := a.Sparse; s != nil {
+		indices, err := l.sparseIndices(a)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse %w", i, err)
+		}
+		values, _, err := l.elements(s.Values.BufferView, s.Values.ByteOffset, s.Count, elementSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse values: %w", i, err)
+		}
+		// values are always tightly packed
+		sparse := make([]float32, s.Count*n)
+		read(sparse, values, elementSize, s.Count)
+		for e, index := range indices {
+			copy(out[int(index)*n:int(index+1)*n], sparse[e*n:(e+1)*n])
+		}
+	}
+
+	return out, n, nil
+}
+
+func (l *loader) sparseIndices(a gltfAccessor) ([]uint32, error) {
+	s := a.Sparse
+	size := componentSize(s.Indices.ComponentType)
+	if size == 0 || s.Indices.ComponentType == componentByte || s.Indices.ComponentType == componentShort || s.Indices.ComponentType == componentFloat {
+		return nil, fmt.Errorf("indices: invalid component type %d", s.Indices.ComponentType)
+	}
+	data, _, err := l.elements(s.Indices.BufferView, s.Indices.ByteOffset, s.Count, size)
+	if err != nil {
+		return nil, fmt.Errorf("indices: %w", err)
+	}
+
+	indices := make([]uint32, s.Count)
+	for i := range indices {
+		indices[i] = readIndex(data[i*size:], s.Indices.ComponentType)
+		if int64(indices[i]) >= int64(a.Count) {
+			return nil, fmt.Errorf("indices: index %d out of range", indices[i])
+		}
+	}
+	return indices, nil
+}
+
+func readIndex(b []byte, componentType int) uint32 {
+	switch componentType {
+	case componentUnsignedByte:
+		return uint32(b[0])
+	case componentUnsignedShort:
+		return uint32(binary.LittleEndian.Uint16(b))
+	default:
+		return binary.LittleEndian.Uint32(b)
+	}
+}
+
+// readIndices returns the elements of the scalar unsigned integer
+// accessor i.
+func (l *loader) readIndices(i int) ([]uint32, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d out of range", i)
+	}
+	a := l.doc.Accessors[i]
+	switch a.ComponentType {
+	case componentUnsignedByte, componentUnsignedShort, componentUnsignedInt:
+	default:
+		return nil, fmt.Errorf("accessor %d: invalid index component type %d", i, a.ComponentType)
+	}
+	if a.Type != "SCALAR" || a.Count < 0 {
+		return nil, fmt.Errorf("accessor %d: invalid index type %q or count %d", i, a.Type, a.Count)
+	}
+	size := componentSize(a.ComponentType)
+
+	var data []byte
+	var stride int
+	if a.BufferView != nil {
+		var err error
+		data, stride, err = l.elements(*a.BufferView, a.ByteOffset, a.Count, size)
+		if err != nil {
+			return nil, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	} else if a.Count > l.maxElements(size) {
+		return nil, fmt.Errorf("accessor %d: count %d exceeds the size of the buffers", i, a.Count)
+	}
+
+	indices := make([]uint32, a.Count)
+	if a.BufferView != nil {
+		for e := range indices {
+			indices[e] = readIndex(data[e*stride:], a.ComponentType)
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		sparse, err := l.sparseIndices(a)
+		if err != nil {
+			return nil, fmt.Errorf("accessor %d: sparse %w", i, err)
+		}
+		values, _, err := l.elements(s.Values.BufferView, s.Values.ByteOffset, s.Count, size)
+		if err != nil {
+			return nil, fmt.Errorf("accessor %d: sparse values: %w", i, err)
+		}
+		for e, index := range sparse {
+			indices[index] = readIndex(values[e*size:], a.ComponentType)
+		}
+	}
+	return indices, nil
+}
@@ -0,0 +1,189 @@
+package gltfloader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/animation"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+// Path is the node property animated by a channel.
+type Path uint8
+
+const (
+	PathTranslation Path = iota
+	PathRotation
+	PathScale
+	// PathWeights animates the morph target weights of the node.
+	PathWeights
+)
+
+// Channel animates a property of a node, only the track matching
+// Path is set. Weights has a track per morph target.
+type Channel struct {
+	Node        int
+	Path        Path
+	Translation *animation.Track[float32, glm.Vec3[float32]]
+	Rotation    *animation.Track[float32, glm.Quaternion[float32]]
+	Scale       *animation.Track[float32, glm.Vec3[float32]]
+	Weights     []*animation.Track[float32, float32]
+}
+
+type Animation struct {
+	Name     string
+	Channels []Channel
+	// Start and End are the times of the first and last keyframes of
+	// all channels.
+	Start, End float32
+}
+
+func (a *Animation) Duration() float32 {
+	return a.End - a.Start
+}
+
+func (l *loader) animation(a gltfAnimation, d *Document) (Animation, error) {
+	anim := Animation{Name: a.Name, Start: float32(math.Inf(1)), End: float32(math.Inf(-1))}
+
+	for i, c := range a.Channels {
+		if c.Sampler < 0 || c.Sampler >= len(a.Samplers) {
+			return anim, fmt.Errorf("channel %d: sampler %d out of range", i, c.Sampler)
+		}
+		if c.Target.Node == nil {
+			// targets of extensions
+			continue
+		}
+		node := *c.Target.Node
+		if node < 0 || node >= len(d.Nodes) {
+			return anim, fmt.Errorf("channel %d: node %d out of range", i, node)
+		}
+		s := a.Samplers[c.Sampler]
+
+		times, n, err := l.readFloats(s.Input)
+		if err != nil {
+			return anim, fmt.Errorf("channel %d: input: %w", i, err)
+		}
+		if n != 1 || len(times) == 0 {
+			return anim, fmt.Errorf("channel %d: input must be non-empty SCALAR", i)
+		}
+		for k := 1; k < len(times); k++ {
+			if times[k] <= times[k-1] {
+				return anim, fmt.Errorf("channel %d: input not increasing", i)
+			}
+		}
+		values, n, err := l.readFloats(s.Output)
+		if err != nil {
+			return anim, fmt.Errorf("channel %d: output: %w", i, err)
+		}
+
+		interpolation := animation.InterpolationLinear
+		switch s.Interpolation {
+		case "", "LINEAR":
+		case "STEP":
+			interpolation = animation.InterpolationStep
+		case "CUBICSPLINE":
+			interpolation = animation.InterpolationCubic
+		default:
+			return anim, fmt.Errorf("channel %d: unknown interpolation %q", i, s.Interpolation)
+		}
+
+		// cubic splines store an in-tangent, value and out-tangent per
+		// keyframe
+		elements := len(times)
+		if interpolation == animation.InterpolationCubic {
+			elements *= 3
+		}
+		channel := Channel{Node: node}
+		switch c.Target.Path {
+		case "translation", "scale":
+			if n != 3 || len(values) != elements*3 {
+				return anim, fmt.Errorf("channel %d: output must be %d VEC3", i, elements)
+			}
+			keyframes := keyframes(times, interpolation, func(e int) glm.Vec3[float32] {
+				return glm.Vec3[float32]{values[e*3], values[e*3+1], values[e*3+2]}
+			})
+			channel.Path = PathTranslation
+			channel.Translation = animation.NewVec3Track(keyframes, interpolation)
+			if c.Target.Path == "scale" {
+				channel.Path = PathScale
+				channel.Translation, channel.Scale = nil, channel.Translation
+			}
+		case "rotation":
+			if n != 4 || len(values) != elements*4 {
+				return anim, fmt.Errorf("channel %d: output must be %d VEC4", i, elements)
+			}
+			channel.Path = PathRotation
+			channel.Rotation = animation.NewQuaternionTrack(keyframes(times, interpolation, func(e int) glm.Quaternion[float32] {
+				v := values[e*4 : e*4+4]
+				return glm.Quaternion[float32]{V: glm.Vec3[float32]{v[0], v[1], v[2]}, S: v[3]}
+			}), interpolation)
+		case "weights":
+			targets := len(d.Nodes[node].Weights)
+			if n != 1 || len(values) != elements*targets {
+				return anim, fmt.Errorf("channel %d: output must be %d SCALAR", i, elements*targets)
+			}
+			channel.Path = PathWeights
+			for t := 0; t < targets; t++ {
+				channel.Weights = append(channel.Weights, animation.NewScalarTrack(keyframes(times, interpolation, func(e int) float32 {
+					return values[e*targets+t]
+				}), interpolation))
+			}
+		default:
+			return anim, fmt.Errorf("channel %d: unknown path %q", i, c.Target.Path)
+		}
+
+		anim.Start = float32(math.Min(float64(anim.Start), float64(times[0])))
+		anim.End = float32(math.Max(float64(anim.End), float64(times[len(times)-1])))
+		anim.Channels = append(anim.Channels, channel)
+	}
+
+	if len(anim.Channels) == 0 {
+		anim.Start, anim.End = 0, 0
+	}
+	return anim, nil
+}
+
+// keyframes creates the keyframes at times from the output elements
+// returned by value.
+func keyframes[V any](times []float32, interpolation animation.Interpolation, value func(e int) V) []animation.Keyframe[float32, V] {
+	keyframes := make([]animation.Keyframe[float32, V], len(times))
+	for k, time := range times {
+		keyframes[k].Time = time
+		if interpolation == animation.InterpolationCubic {
+			keyframes[k].InTangent = value(k * 3)
+			keyframes[k].Value = value(k*3 + 1)
+			keyframes[k].OutTangent = value(k*3 + 2)
+		} else {
+			keyframes[k].Value = value(k)
+		}
+	}
+	return keyframes
+}
+
+// Animate poses the nodes of d at time in animation, looping it.
+// Nodes not targeted by the animation keep their transform.
+func (d *Document) Animate(animation int, time float32) {
+	a := &d.Animations[animation]
+	if duration := a.Duration(); duration > 0 {
+		time = a.Start + float32(math.Mod(float64(time-a.Start), float64(duration)))
+		if time < a.Start {
+			time += duration
+		}
+	}
+
+	for _, c := range a.Channels {
+		node := &d.Nodes[c.Node]
+		switch c.Path {
+		case PathTranslation:
+			node.Transform.SetTranslation(c.Translation.Sample(time))
+		case PathRotation:
+			node.Transform.SetRotation(c.Rotation.Sample(time).Normalize())
+		case PathScale:
+			node.Transform.SetScale(c.Scale.Sample(time))
+		case PathWeights:
+			for t, track := range c.Weights {
+				node.Weights[t] = track.Sample(time)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gltfloader
+
+import (
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// MeshInstance is a mesh placed in a scene by a node.
+type MeshInstance struct {
+	Mesh int
+	Node int
+	// Skin is the skin of the node, -1 if none.
+	Skin  int
+	World glm.Mat4[float32]
+}
+
+// MeshInstances returns the meshes of scene s in node order. A
+// negative s uses the document's scene, or the first one if it has
+// none; a document without scenes shows every root node.
+func (d *Document) MeshInstances(s int) []MeshInstance {
+	roots := []int{}
+	switch {
+	case s >= 0 && s < len(d.Scenes):
+		roots = d.Scenes[s].Nodes
+	case s < 0 && d.Scene >= 0:
+		roots = d.Scenes[d.Scene].Nodes
+	case s < 0 && len(d.Scenes) != 0:
+		roots = d.Scenes[0].Nodes
+	case s < 0:
+		for i, n := range d.Nodes {
+			if n.Transform.Parent() == nil {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	instances := []MeshInstance{}
+	var walk func(i int)
+	walk = func(i int) {
+		n := d.Nodes[i]
+		if n.Mesh != -1 {
+			instances = append(instances, MeshInstance{Mesh: n.Mesh, Node: i, Skin: n.Skin, World: n.Transform.WorldMatrix()})
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, r := range roots {
+		walk(r)
+	}
+	return instances
+}
+
+// Flatten returns a copy of every mesh instance of scene s, see
+// MeshInstances, morphed by the weights of its node and with the world
+// transform applied to its positions, normals and tangents. Skinned
+// meshes are left in their bind pose instead, with their Joints offset
+// to index the joint matrices returned by JointMatrices, other meshes
+// lose their joints.
+func (d *Document) Flatten(s int) []objloader.Model {
+	models := []objloader.Model{}
+	for _, inst := range d.MeshInstances(s) {
+		mesh := d.Meshes[inst.Mesh]
+		if targets := d.MorphTargets[inst.Mesh]; len(targets) != 0 {
+			mesh = Morph(&mesh, targets, d.Nodes[inst.Node].Weights)
+		}
+
+		if inst.Skin == -1 {
+			// joints are meaningless without a skin
+			m := transformModel(mesh, inst.World)
+			m.Joints, m.Weights = nil, nil
+			models = append(models, m)
+			continue
+		}
+
+		m := transformModel(mesh, glm.Mat4Identity[float32]())
+		offset := uint16(d.jointOffset(inst.Skin))
+		m.Joints = make([][4]uint16, len(d.Meshes[inst.Mesh].Joints))
+		for v, joints := range d.Meshes[inst.Mesh].Joints {
+			for i, j := range joints {
+				m.Joints[v][i] = offset + j
+			}
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+func transformModel(m objloader.Model, world glm.Mat4[float32]) objloader.Model {
+	out := m
+	out.Submeshes = append([]objloader.Submesh(nil), m.Submeshes...)
+	out.TextureCoords = append([][3]float32(nil), m.TextureCoords...)
+	out.Colors = append([][3]float32(nil), m.Colors...)
+	out.Joints = append([][4]uint16(nil), m.Joints...)
+	out.Weights = append([][4]float32(nil), m.Weights...)
+	out.LineIndices = append([]uint32(nil), m.LineIndices...)
+	out.PointIndices = append([]uint32(nil), m.PointIndices...)
+
+	out.Vertices = make([][3]float32, len(m.Vertices))
+	for i, v := range m.Vertices {
+		out.Vertices[i] = world.TransformPoint(v)
+	}
+
+	normalMatrix := world.NormalMatrix()
+	out.Normals = make([][3]float32, len(m.Normals))
+	for i, n := range m.Normals {
+		if n := normalMatrix.MulVec3(n); n.Magnitude() > 0 {
+			out.Normals[i] = n.Normalize()
+		}
+	}
+
+	// mirroring transforms flip the handedness of the tangent frame
+	// and the winding of the triangles
+	mirrored := world.Determinant() < 0
+	out.Tangents = make([][4]float32, len(m.Tangents))
+	for i, t := range m.Tangents {
+		tangent := world.TransformDirection(glm.Vec3[float32]{t[0], t[1], t[2]})
+		if tangent.Magnitude() > 0 {
+			tangent = tangent.Normalize()
+		}
+		w := t[3]
+		if mirrored {
+			w = -w
+		}
+		out.Tangents[i] = [4]float32{tangent[0], tangent[1], tangent[2], w}
+	}
+
+	out.Indices = append([]uint32(nil), m.Indices...)
+	if mirrored {
+		for i := 0; i+2 < len(out.Indices); i += 3 {
+			out.Indices[i+1], out.Indices[i+2] = out.Indices[i+2], out.Indices[i+1]
+		}
+	}
+	return out
+}
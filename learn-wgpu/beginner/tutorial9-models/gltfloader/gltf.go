@@ -0,0 +1,605 @@
+// Package gltfloader loads glTF 2.0 assets, both .gltf JSON files and
+// .glb binary containers. Meshes are converted to objloader models so
+// that they can be processed and drawn like obj files.
+package gltfloader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/scene"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// Document is a loaded glTF asset. Elements refer to each other by
+// their index in the respective slice, -1 meaning none.
+type Document struct {
+	// Meshes has a model for every glTF mesh, with a submesh for
+	// every triangle primitive.
+	Meshes    []objloader.Model
+	Materials []Material
+	Textures  []Texture
+	Images    []Image
+	Samplers  []Sampler
+	// MorphTargets has the morph targets of every mesh.
+	MorphTargets [][]MorphTarget
+	Nodes        []Node
+	Scenes       []Scene
+	Skins        []Skin
+	Animations   []Animation
+	// Scene is the scene to display, -1 if unspecified.
+	Scene int
+}
+
+// Node is an element of the node hierarchy. The children of a node are
+// also attached to its Transform.
+type Node struct {
+	Transform *scene.Node[float32]
+	Mesh      int
+	// Skin deforms the mesh, whose vertices are then relative to the
+	// skin's joints instead of the node.
+	Skin int
+	// Weights are the morph target weights of the mesh.
+	Weights  []float32
+	Children []int
+}
+
+type Scene struct {
+	Name string
+	// Nodes are the root nodes of the scene.
+	Nodes []int
+}
+
+type AlphaMode uint8
+
+const (
+	AlphaOpaque AlphaMode = iota
+	// AlphaMask discards fragments with alpha below AlphaCutoff.
+	AlphaMask
+	AlphaBlend
+)
+
+// Material is a metallic-roughness material. Material names are made
+// unique, unnamed materials are named after their index, as they are
+// referenced by name from objloader.Submesh.
+type Material struct {
+	Name                     string
+	BaseColorFactor          [4]float32
+	BaseColorTexture         TextureInfo
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture TextureInfo
+	// NormalTexture's Scale scales the normals' x and y.
+	NormalTexture TextureInfo
+	// OcclusionTexture's Scale is the occlusion strength.
+	OcclusionTexture TextureInfo
+	EmissiveTexture  TextureInfo
+	EmissiveFactor   [3]float32
+	AlphaMode        AlphaMode
+	AlphaCutoff      float32
+	DoubleSided      bool
+}
+
+// TextureInfo is a reference to a texture, Texture is -1 if the
+// material doesn't use one.
+type TextureInfo struct {
+	Texture int
+	// TexCoord is the set of texture coordinates used, only set 0 is
+	// loaded.
+	TexCoord int
+	Scale    float32
+}
+
+type Texture struct {
+	Name    string
+	Image   int
+	Sampler int
+}
+
+// Image is an encoded image, usually PNG or JPEG.
+type Image struct {
+	Name     string
+	MimeType string
+	Data     []byte
+}
+
+// Sampler filter and wrap modes, as defined by glTF.
+const (
+	FilterNearest              = 9728
+	FilterLinear               = 9729
+	FilterNearestMipmapNearest = 9984
+	FilterLinearMipmapNearest  = 9985
+	FilterNearestMipmapLinear  = 9986
+	FilterLinearMipmapLinear   = 9987
+
+	WrapClampToEdge    = 33071
+	WrapMirroredRepeat = 33648
+	WrapRepeat         = 10497
+)
+
+// Sampler holds the filter and wrap modes of a texture, filters are 0
+// if unspecified.
+type Sampler struct {
+	Name      string
+	MagFilter int
+	MinFilter int
+	WrapS     int
+	WrapT     int
+}
+
+// Resolver opens a file referenced by uri from a glTF file, i.e. an
+// external buffer or image.
+type Resolver func(uri string) (io.ReadCloser, error)
+
+// FSResolver resolves references relative to the glTF file name in
+// dir.
+func FSResolver(dir fs.FS, name string) Resolver {
+	return func(uri string) (io.ReadCloser, error) {
+		p, err := url.PathUnescape(uri)
+		if err != nil {
+			return nil, err
+		}
+		return dir.Open(path.Join(path.Dir(name), p))
+	}
+}
+
+// Load loads the .gltf or .glb file name from dir.
+func Load(dir fs.FS, name string) (*Document, error) {
+	f, err := dir.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadReader(f, FSResolver(dir, name))
+}
+
+const (
+	glbMagic     = 0x46546c67 // "glTF"
+	glbChunkJSON = 0x4e4f534a // "JSON"
+	glbChunkBIN  = 0x004e4942 // "BIN\x00"
+)
+
+// LoadReader loads the glTF JSON or GLB data read from r. resolve may
+// be nil, in which case only embedded buffers and images are
+// supported.
+func LoadReader(r io.Reader, resolve Resolver) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonChunk, bin := data, []byte(nil)
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		jsonChunk, bin, err = parseGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var doc gltfDocument
+	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
+		return nil, fmt.Errorf("gltf: %w", err)
+	}
+	if !strings.HasPrefix(doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: unsupported version %q", doc.Asset.Version)
+	}
+	for _, ext := range doc.ExtensionsRequired {
+		if ext != "KHR_mesh_quantization" {
+			return nil, fmt.Errorf("gltf: unsupported required extension %s", ext)
+		}
+	}
+
+	l := &loader{doc: &doc, bin: bin, resolve: resolve}
+	return l.load()
+}
+
+// parseGLB returns the JSON and binary chunks of a GLB container.
+func parseGLB(data []byte) (jsonChunk, bin []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb: truncated header")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("glb: unsupported version %d", version)
+	}
+	length := binary.LittleEndian.Uint32(data[8:])
+	if length < 12 || int64(length) > int64(len(data)) {
+		return nil, nil, errors.New("glb: truncated file")
+	}
+
+	chunks := data[12:length]
+	for i := 0; len(chunks) != 0; i++ {
+		if len(chunks) < 8 {
+			return nil, nil, errors.New("glb: truncated chunk header")
+		}
+		chunkLength := binary.LittleEndian.Uint32(chunks)
+		chunkType := binary.LittleEndian.Uint32(chunks[4:])
+		if int64(chunkLength) > int64(len(chunks)-8) {
+			return nil, nil, errors.New("glb: truncated chunk")
+		}
+		chunk := chunks[8 : 8+chunkLength]
+		chunks = chunks[8+chunkLength:]
+
+		switch {
+		case i == 0 && chunkType == glbChunkJSON:
+			jsonChunk = chunk
+		case i == 0:
+			return nil, nil, errors.New("glb: first chunk is not JSON")
+		case i == 1 && chunkType == glbChunkBIN:
+			bin = chunk
+		default:
+			// unknown chunks must be ignored
+		}
+	}
+	return jsonChunk, bin, nil
+}
+
+type loader struct {
+	doc     *gltfDocument
+	bin     []byte
+	resolve Resolver
+
+	buffers [][]byte
+}
+
+func (l *loader) load() (*Document, error) {
+	d := &Document{Scene: -1}
+	doc := l.doc
+
+	l.buffers = make([][]byte, len(doc.Buffers))
+	for i, b := range doc.Buffers {
+		data, err := l.buffer(i, b)
+		if err != nil {
+			return nil, fmt.Errorf("buffer %d: %w", i, err)
+		}
+		if b.ByteLength < 0 {
+			return nil, fmt.Errorf("buffer %d: negative byte length %d", i, b.ByteLength)
+		}
+		if len(data) < b.ByteLength {
+			return nil, fmt.Errorf("buffer %d: %d bytes, want %d", i, len(data), b.ByteLength)
+		}
+		l.buffers[i] = data[:b.ByteLength]
+	}
+
+	for i, img := range doc.Images {
+		image, err := l.image(img)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+		d.Images = append(d.Images, image)
+	}
+
+	for _, s := range doc.Samplers {
+		sampler := Sampler{Name: s.Name, MagFilter: s.MagFilter, MinFilter: s.MinFilter, WrapS: WrapRepeat, WrapT: WrapRepeat}
+		if s.WrapS != nil {
+			sampler.WrapS = *s.WrapS
+		}
+		if s.WrapT != nil {
+			sampler.WrapT = *s.WrapT
+		}
+		d.Samplers = append(d.Samplers, sampler)
+	}
+
+	for i, t := range doc.Textures {
+		texture := Texture{Name: t.Name, Image: -1, Sampler: -1}
+		if t.Source != nil {
+			if *t.Source < 0 || *t.Source >= len(d.Images) {
+				return nil, fmt.Errorf("texture %d: image %d out of range", i, *t.Source)
+			}
+			texture.Image = *t.Source
+		}
+		if t.Sampler != nil {
+			if *t.Sampler < 0 || *t.Sampler >= len(d.Samplers) {
+				return nil, fmt.Errorf("texture %d: sampler %d out of range", i, *t.Sampler)
+			}
+			texture.Sampler = *t.Sampler
+		}
+		d.Textures = append(d.Textures, texture)
+	}
+
+	names := map[string]bool{}
+	for i, m := range doc.Materials {
+		material, err := l.material(m, len(d.Textures))
+		if err != nil {
+			return nil, fmt.Errorf("material %d: %w", i, err)
+		}
+		switch {
+		case material.Name == "":
+			material.Name = fmt.Sprintf("material_%d", i)
+		case names[material.Name]:
+			material.Name = fmt.Sprintf("%s_%d", material.Name, i)
+		}
+		names[material.Name] = true
+		d.Materials = append(d.Materials, material)
+	}
+
+	for i, m := range doc.Meshes {
+		model, targets, err := l.mesh(m, d.Materials)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d: %w", i, err)
+		}
+		d.Meshes = append(d.Meshes, model)
+		d.MorphTargets = append(d.MorphTargets, targets)
+	}
+
+	if err := l.nodes(d); err != nil {
+		return nil, err
+	}
+
+	for i, s := range doc.Skins {
+		skin, err := l.skin(s, len(d.Nodes))
+		if err != nil {
+			return nil, fmt.Errorf("skin %d: %w", i, err)
+		}
+		d.Skins = append(d.Skins, skin)
+	}
+	// Flatten offsets the joints of every skin into one uint16 range
+	if joints := d.jointOffset(len(d.Skins)); joints > math.MaxUint16+1 {
+		return nil, fmt.Errorf("%d joints, at most %d are supported", joints, math.MaxUint16+1)
+	}
+	for i, n := range doc.Nodes {
+		if n.Skin == nil {
+			continue
+		}
+		if *n.Skin < 0 || *n.Skin >= len(d.Skins) {
+			return nil, fmt.Errorf("node %d: skin %d out of range", i, *n.Skin)
+		}
+		if d.Nodes[i].Mesh == -1 {
+			return nil, fmt.Errorf("node %d: skin without mesh", i)
+		}
+		// an index beyond the joints of the skin would read the joint
+		// matrices of another one
+		if err := checkJoints(d.Meshes[d.Nodes[i].Mesh], len(d.Skins[*n.Skin].Joints)); err != nil {
+			return nil, fmt.Errorf("node %d: mesh %d: %w", i, d.Nodes[i].Mesh, err)
+		}
+		d.Nodes[i].Skin = *n.Skin
+	}
+
+	for i, a := range doc.Animations {
+		animation, err := l.animation(a, d)
+		if err != nil {
+			return nil, fmt.Errorf("animation %d: %w", i, err)
+		}
+		d.Animations = append(d.Animations, animation)
+	}
+
+	for i, s := range doc.Scenes {
+		for _, n := range s.Nodes {
+			if n < 0 || n >= len(d.Nodes) {
+				return nil, fmt.Errorf("scene %d: node %d out of range", i, n)
+			}
+			if d.Nodes[n].Transform.Parent() != nil {
+				return nil, fmt.Errorf("scene %d: node %d is not a root node", i, n)
+			}
+		}
+		d.Scenes = append(d.Scenes, Scene{Name: s.Name, Nodes: s.Nodes})
+	}
+	if doc.Scene != nil {
+		if *doc.Scene < 0 || *doc.Scene >= len(d.Scenes) {
+			return nil, fmt.Errorf("scene %d out of range", *doc.Scene)
+		}
+		d.Scene = *doc.Scene
+	}
+
+	return d, nil
+}
+
+// buffer returns the contents of buffer i, either the GLB binary
+// chunk, a data URI or an external file.
+func (l *loader) buffer(i int, b gltfBuffer) ([]byte, error) {
+	if b.URI == "" {
+		if i != 0 || l.bin == nil {
+			return nil, errors.New("missing uri")
+		}
+		return l.bin, nil
+	}
+	return l.uri(b.URI)
+}
+
+func (l *loader) uri(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		header, data, ok := strings.Cut(uri, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, errors.New("unsupported data uri")
+		}
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	if l.resolve == nil {
+		return nil, fmt.Errorf("external uri %q without resolver", uri)
+	}
+	f, err := l.resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+func (l *loader) image(img gltfImage) (Image, error) {
+	image := Image{Name: img.Name, MimeType: img.MimeType}
+
+	switch {
+	case img.BufferView != nil:
+		data, _, err := l.bufferView(*img.BufferView)
+		if err != nil {
+			return image, err
+		}
+		image.Data = data
+	case img.URI != "":
+		data, err := l.uri(img.URI)
+		if err != nil {
+			return image, err
+		}
+		image.Data = data
+		if image.MimeType == "" && strings.HasPrefix(img.URI, "data:") {
+			image.MimeType, _, _ = strings.Cut(strings.TrimPrefix(img.URI, "data:"), ";")
+		}
+	default:
+		return image, errors.New("missing uri and buffer view")
+	}
+
+	if image.MimeType == "" {
+		switch {
+		case bytes.HasPrefix(image.Data, []byte("\x89PNG")):
+			image.MimeType = "image/png"
+		case bytes.HasPrefix(image.Data, []byte("\xff\xd8\xff")):
+			image.MimeType = "image/jpeg"
+		}
+	}
+	return image, nil
+}
+
+// bufferView returns the bytes of buffer view i and its stride, 0 if
+// tightly packed.
+func (l *loader) bufferView(i int) ([]byte, int, error) {
+	if i < 0 || i >= len(l.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d out of range", i)
+	}
+	v := l.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, fmt.Errorf("buffer view %d: buffer %d out of range", i, v.Buffer)
+	}
+	buf := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(buf) || v.ByteLength > len(buf)-v.ByteOffset {
+		return nil, 0, fmt.Errorf("buffer view %d out of bounds", i)
+	}
+	if v.ByteStride != 0 && (v.ByteStride < 4 || v.ByteStride > 252 || v.ByteStride%4 != 0) {
+		return nil, 0, fmt.Errorf("buffer view %d: invalid byte stride %d", i, v.ByteStride)
+	}
+	return buf[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+func (l *loader) material(m gltfMaterial, numTextures int) (Material, error) {
+	material := Material{
+		Name:                     m.Name,
+		BaseColorFactor:          [4]float32{1, 1, 1, 1},
+		BaseColorTexture:         TextureInfo{Texture: -1},
+		MetallicFactor:           1,
+		RoughnessFactor:          1,
+		MetallicRoughnessTexture: TextureInfo{Texture: -1},
+		EmissiveFactor:           m.EmissiveFactor,
+		AlphaCutoff:              0.5,
+		DoubleSided:              m.DoubleSided,
+	}
+
+	var err error
+	textureInfo := func(info *gltfTextureInfo) TextureInfo {
+		if info == nil {
+			return TextureInfo{Texture: -1, Scale: 1}
+		}
+		if info.Index < 0 || info.Index >= numTextures {
+			err = fmt.Errorf("texture %d out of range", info.Index)
+		}
+		t := TextureInfo{Texture: info.Index, TexCoord: info.TexCoord, Scale: 1}
+		if info.Scale != nil {
+			t.Scale = *info.Scale
+		}
+		if info.Strength != nil {
+			t.Scale = *info.Strength
+		}
+		return t
+	}
+
+	if pbr := m.PBRMetallicRoughness; pbr != nil {
+		if pbr.BaseColorFactor != nil {
+			material.BaseColorFactor = *pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			material.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			material.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		material.BaseColorTexture = textureInfo(pbr.BaseColorTexture)
+		material.MetallicRoughnessTexture = textureInfo(pbr.MetallicRoughnessTexture)
+	}
+	material.NormalTexture = textureInfo(m.NormalTexture)
+	material.OcclusionTexture = textureInfo(m.OcclusionTexture)
+	material.EmissiveTexture = textureInfo(m.EmissiveTexture)
+
+	switch m.AlphaMode {
+	case "", "OPAQUE":
+	case "MASK":
+		material.AlphaMode = AlphaMask
+	case "BLEND":
+		material.AlphaMode = AlphaBlend
+	default:
+		return material, fmt.Errorf("unknown alpha mode %q", m.AlphaMode)
+	}
+	if m.AlphaCutoff != nil {
+		material.AlphaCutoff = *m.AlphaCutoff
+	}
+
+	return material, err
+}
+
+// nodes builds the node hierarchy, every node must have at most one
+// parent and the hierarchy must not have cycles.
+func (l *loader) nodes(d *Document) error {
+	for i, n := range l.doc.Nodes {
+		node := Node{Transform: scene.NewNode[float32](n.Name), Mesh: -1, Skin: -1, Children: n.Children}
+		if n.Mesh != nil {
+			if *n.Mesh < 0 || *n.Mesh >= len(d.Meshes) {
+				return fmt.Errorf("node %d: mesh %d out of range", i, *n.Mesh)
+			}
+			node.Mesh = *n.Mesh
+
+			// the weights of the node override those of the mesh
+			targets := len(d.MorphTargets[node.Mesh])
+			weights := l.doc.Meshes[node.Mesh].Weights
+			if n.Weights != nil {
+				weights = n.Weights
+			}
+			if len(weights) != 0 && len(weights) != targets {
+				return fmt.Errorf("node %d: %d weights for %d morph targets", i, len(weights), targets)
+			}
+			node.Weights = make([]float32, targets)
+			copy(node.Weights, weights)
+		}
+
+		if n.Matrix != nil {
+			node.Transform.SetLocalMatrix(glm.Mat4[float32](*n.Matrix))
+		} else {
+			if n.Translation != nil {
+				node.Transform.SetTranslation(*n.Translation)
+			}
+			if n.Rotation != nil {
+				r := *n.Rotation
+				node.Transform.SetRotation(glm.Quaternion[float32]{V: glm.Vec3[float32]{r[0], r[1], r[2]}, S: r[3]})
+			}
+			if n.Scale != nil {
+				node.Transform.SetScale(*n.Scale)
+			}
+		}
+		d.Nodes = append(d.Nodes, node)
+	}
+
+	for i, n := range d.Nodes {
+		for _, c := range n.Children {
+			if c < 0 || c >= len(d.Nodes) {
+				return fmt.Errorf("node %d: child %d out of range", i, c)
+			}
+			child := d.Nodes[c].Transform
+			if child.Parent() != nil {
+				return fmt.Errorf("node %d: child %d has several parents", i, c)
+			}
+			if err := n.Transform.AddChild(child); err != nil {
+				return fmt.Errorf("node %d: child %d: %w", i, c, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package gltfloader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// triangleDocument returns a glTF document with one triangle whose
+// POSITION accessor reads the buffer view as given.
+func triangleDocument(bufferView, accessor string) string {
+	data := base64.StdEncoding.EncodeToString(make([]byte, 36))
+	return fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"byteLength": 36, "uri": "data:application/octet-stream;base64,%s"}],
+		"bufferViews": [%s],
+		"accessors": [%s],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}]
+	}`, data, bufferView, accessor)
+}
+
+func TestLoadReaderAccessorBounds(t *testing.T) {
+	const vec3 = `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`
+
+	if _, err := LoadReader(strings.NewReader(triangleDocument(`{"buffer": 0, "byteLength": 36}`, vec3)), nil); err != nil {
+		t.Fatalf("valid document: %v", err)
+	}
+
+	// a view whose end overflows int
+	const hugeView = `{"buffer": 0, "byteOffset": 9223372036854775800, "byteLength": 100}`
+	negativeBuffer := strings.Replace(triangleDocument(`{"buffer": 0, "byteLength": 36}`, vec3), `"byteLength": 36, "uri"`, `"byteLength": -1, "uri"`, 1)
+
+	for _, tt := range []struct {
+		name, doc string
+	}{
+		{"negative stride", triangleDocument(`{"buffer": 0, "byteLength": 36, "byteStride": -4}`, vec3)},
+		{"unaligned stride", triangleDocument(`{"buffer": 0, "byteLength": 36, "byteStride": 6}`, vec3)},
+		{"stride above 252", triangleDocument(`{"buffer": 0, "byteLength": 36, "byteStride": 256}`, vec3)},
+		{"stride below element size", triangleDocument(`{"buffer": 0, "byteLength": 36, "byteStride": 4}`, vec3)},
+		{"count beyond view", triangleDocument(`{"buffer": 0, "byteLength": 36}`, `{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}`)},
+		{"huge count", triangleDocument(`{"buffer": 0, "byteLength": 36}`, `{"bufferView": 0, "componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`)},
+		{"huge count without view", triangleDocument(`{"buffer": 0, "byteLength": 36}`, `{"componentType": 5126, "count": 100000000, "type": "VEC3"}`)},
+		{"negative buffer length", negativeBuffer},
+		{"view end overflows", triangleDocument(hugeView, vec3)},
+		{"image view end overflows", strings.Replace(triangleDocument(hugeView, vec3), `"accessors"`, `"images": [{"bufferView": 0, "mimeType": "image/png"}], "accessors"`, 1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadReader(strings.NewReader(tt.doc), nil); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
+
+func TestFlattenMorph(t *testing.T) {
+	// a triangle at the origin and a morph target moving it up by 2
+	data := make([]byte, 72)
+	for _, offset := range []int{40, 52, 64} {
+		binary.LittleEndian.PutUint32(data[offset:], math.Float32bits(2))
+	}
+	doc := fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"byteLength": 72, "uri": "data:application/octet-stream;base64,%s"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 72}],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			{"bufferView": 0, "byteOffset": 36, "componentType": 5126, "count": 3, "type": "VEC3"}
+		],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "targets": [{"POSITION": 1}]}], "weights": [0.25]}],
+		"nodes": [{"mesh": 0}, {"mesh": 0, "weights": [1]}]
+	}`, base64.StdEncoding.EncodeToString(data))
+
+	d, err := LoadReader(strings.NewReader(doc), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	models := d.Flatten(-1)
+	if len(models) != 2 {
+		t.Fatalf("%d models, want 2", len(models))
+	}
+	// the first node uses the weights of the mesh, the second its own
+	for i, want := range []float32{0.5, 2} {
+		for _, v := range models[i].Vertices {
+			if v != [3]float32{0, want, 0} {
+				t.Errorf("node %d: vertex %v, want y %v", i, v, want)
+			}
+		}
+	}
+}
+
+// glbDocument wraps the JSON document in a GLB container with an
+// empty binary chunk.
+func glbDocument(document string) []byte {
+	jsonChunk := []byte(document)
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+	b := binary.LittleEndian.AppendUint32(nil, glbMagic)
+	b = binary.LittleEndian.AppendUint32(b, 2)
+	b = binary.LittleEndian.AppendUint32(b, uint32(12+8+len(jsonChunk)+8))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(jsonChunk)))
+	b = binary.LittleEndian.AppendUint32(b, glbChunkJSON)
+	b = append(b, jsonChunk...)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	return binary.LittleEndian.AppendUint32(b, glbChunkBIN)
+}
+
+// FuzzLoadReader checks that malformed glTF and GLB input comes back as
+// an error instead of a panic or a huge allocation, and that loaded
+// documents can be flattened and animated.
+func FuzzLoadReader(f *testing.F) {
+	triangle := triangleDocument(`{"buffer": 0, "byteLength": 36}`, `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`)
+	f.Add([]byte(triangle))
+	f.Add(glbDocument(triangle))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, err := LoadReader(bytes.NewReader(data), nil)
+		if err != nil {
+			return
+		}
+		d.Flatten(-1)
+		d.JointMatrices(nil)
+		for i, a := range d.Animations {
+			d.Animate(i, a.Start+a.Duration()/2)
+		}
+	})
+}
+
+// skinnedTriangle returns a glTF document with a triangle skinned to
+// a single joint. Its vertices are weighted to joint 0 and to joint
+// with weight.
+func skinnedTriangle(joint uint8, weight float32) string {
+	data := make([]byte, 96)
+	for v := 0; v < 3; v++ {
+		data[36+v*4], data[36+v*4+1] = 0, joint
+		binary.LittleEndian.PutUint32(data[48+v*16:], math.Float32bits(1-weight))
+		binary.LittleEndian.PutUint32(data[48+v*16+4:], math.Float32bits(weight))
+	}
+	return fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"byteLength": 96, "uri": "data:application/octet-stream;base64,%s"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 96}],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			{"bufferView": 0, "byteOffset": 36, "componentType": 5121, "count": 3, "type": "VEC4"},
+			{"bufferView": 0, "byteOffset": 48, "componentType": 5126, "count": 3, "type": "VEC4"}
+		],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "JOINTS_0": 1, "WEIGHTS_0": 2}}]}],
+		"skins": [{"joints": [1]}],
+		"nodes": [{"mesh": 0, "skin": 0}, {}]
+	}`, base64.StdEncoding.EncodeToString(data))
+}
+
+func TestLoadReaderJointBounds(t *testing.T) {
+	if _, err := LoadReader(strings.NewReader(skinnedTriangle(0, 0.5)), nil); err != nil {
+		t.Errorf("valid joints: %v", err)
+	}
+	// unweighted joints aren't used
+	if _, err := LoadReader(strings.NewReader(skinnedTriangle(1, 0)), nil); err != nil {
+		t.Errorf("unweighted joint out of range: %v", err)
+	}
+	if _, err := LoadReader(strings.NewReader(skinnedTriangle(1, 0.5)), nil); err == nil {
+		t.Error("joint out of range: want an error")
+	}
+}
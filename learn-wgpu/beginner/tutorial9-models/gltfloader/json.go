@@ -0,0 +1,159 @@
+package gltfloader
+
+// The glTF 2.0 JSON schema, only the properties used by the loader
+// are declared. Indices that may be absent are pointers.
+
+type gltfDocument struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+	ExtensionsUsed     []string `json:"extensionsUsed"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene       *int             `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+	Samplers    []gltfSampler    `json:"samplers"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Skins       []gltfSkin       `json:"skins"`
+	Animations  []gltfAnimation  `json:"animations"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Skin        *int         `json:"skin"`
+	Weights     []float32    `json:"weights"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+	Weights    []float32       `json:"weights"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices"`
+	Material   *int             `json:"material"`
+	Mode       *int             `json:"mode"`
+	Targets    []map[string]int `json:"targets"`
+}
+
+type gltfTextureInfo struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          *[4]float32      `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32         `json:"metallicFactor"`
+		RoughnessFactor          *float32         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *gltfTextureInfo `json:"normalTexture"`
+	OcclusionTexture *gltfTextureInfo `json:"occlusionTexture"`
+	EmissiveTexture  *gltfTextureInfo `json:"emissiveTexture"`
+	EmissiveFactor   [3]float32       `json:"emissiveFactor"`
+	AlphaMode        string           `json:"alphaMode"`
+	AlphaCutoff      *float32         `json:"alphaCutoff"`
+	DoubleSided      bool             `json:"doubleSided"`
+}
+
+type gltfTexture struct {
+	Name    string `json:"name"`
+	Sampler *int   `json:"sampler"`
+	Source  *int   `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type gltfSampler struct {
+	Name      string `json:"name"`
+	MagFilter int    `json:"magFilter"`
+	MinFilter int    `json:"minFilter"`
+	WrapS     *int   `json:"wrapS"`
+	WrapT     *int   `json:"wrapT"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	Sparse        *struct {
+		Count   int `json:"count"`
+		Indices struct {
+			BufferView    int `json:"bufferView"`
+			ByteOffset    int `json:"byteOffset"`
+			ComponentType int `json:"componentType"`
+		} `json:"indices"`
+		Values struct {
+			BufferView int `json:"bufferView"`
+			ByteOffset int `json:"byteOffset"`
+		} `json:"values"`
+	} `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfSkin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Joints              []int  `json:"joints"`
+}
+
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
@@ -0,0 +1,353 @@
+package gltfloader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// Primitive topologies.
+const (
+	modePoints = iota
+	modeLines
+	modeLineLoop
+	modeLineStrip
+	modeTriangles
+	modeTriangleStrip
+	modeTriangleFan
+)
+
+// primitive is a decoded glTF primitive before being merged into a
+// model.
+type primitive struct {
+	positions []float32
+	normals   []float32
+	texCoords []float32
+	colors    []float32
+	// colorComponents is 3 or 4, alpha is dropped
+	colorComponents int
+	tangents        []float32
+	joints          []float32
+	weights         []float32
+	// targets holds the POSITION, NORMAL and TANGENT displacements of
+	// every morph target, nil if missing
+	targets  [][3][]float32
+	indices  []uint32
+	mode     int
+	material int
+}
+
+// mesh merges the primitives of m into a single model. Triangle
+// primitives get a submesh each, line and point primitives end up in
+// LineIndices and PointIndices. Attributes present in any primitive
+// are filled in for the others: primitives without normals get smooth
+// ones, without colours white and without joints no weights.
+// Tangents are only loaded if every primitive has them.
+func (l *loader) mesh(m gltfMesh, materials []Material) (objloader.Model, []MorphTarget, error) {
+	model := objloader.Model{Name: m.Name}
+	if len(m.Primitives) == 0 {
+		return model, nil, fmt.Errorf("no primitives")
+	}
+
+	primitives := []primitive{}
+	hasNormals, hasTexCoords, hasColors, hasTangents := false, false, false, true
+	hasSkin := false
+	for i, p := range m.Primitives {
+		prim, err := l.primitive(p, len(materials))
+		if err != nil {
+			return model, nil, fmt.Errorf("primitive %d: %w", i, err)
+		}
+		if len(prim.targets) != len(m.Primitives[0].Targets) {
+			return model, nil, fmt.Errorf("primitive %d: %d morph targets, want %d", i, len(prim.targets), len(m.Primitives[0].Targets))
+		}
+		hasNormals = hasNormals || prim.normals != nil
+		hasTexCoords = hasTexCoords || prim.texCoords != nil
+		hasColors = hasColors || prim.colors != nil
+		hasTangents = hasTangents && prim.tangents != nil
+		hasSkin = hasSkin || prim.joints != nil
+		primitives = append(primitives, prim)
+	}
+	model.LinearColors = hasColors
+	if len(m.Weights) != 0 && len(m.Weights) != len(m.Primitives[0].Targets) {
+		return model, nil, fmt.Errorf("%d weights for %d morph targets", len(m.Weights), len(m.Primitives[0].Targets))
+	}
+
+	targets := make([]MorphTarget, len(m.Primitives[0].Targets))
+
+	for _, prim := range primitives {
+		base := uint32(len(model.Vertices))
+		count := len(prim.positions) / 3
+
+		if hasNormals && prim.normals == nil {
+			prim.normals = smoothNormals(prim)
+		}
+		for v := 0; v < count; v++ {
+			model.Vertices = append(model.Vertices, [3]float32(vec3(prim.positions, v)))
+			if hasNormals {
+				model.Normals = append(model.Normals, [3]float32(vec3(prim.normals, v)))
+			}
+			if hasTexCoords {
+				var uv [3]float32
+				if prim.texCoords != nil {
+					uv = [3]float32{prim.texCoords[v*2], prim.texCoords[v*2+1], 0}
+				}
+				model.TextureCoords = append(model.TextureCoords, uv)
+			}
+			if hasColors {
+				color := [3]float32{1, 1, 1}
+				if prim.colors != nil {
+					n := prim.colorComponents
+					copy(color[:], prim.colors[v*n:v*n+3])
+				}
+				model.Colors = append(model.Colors, color)
+			}
+			if hasTangents {
+				model.Tangents = append(model.Tangents, [4]float32(prim.tangents[v*4:v*4+4]))
+			}
+			if hasSkin {
+				var joints [4]uint16
+				var weights [4]float32
+				if prim.joints != nil {
+					for c := range joints {
+						joints[c] = uint16(prim.joints[v*4+c])
+					}
+					copy(weights[:], prim.weights[v*4:v*4+4])
+				}
+				model.Joints = append(model.Joints, joints)
+				model.Weights = append(model.Weights, weights)
+			}
+
+			for t, target := range prim.targets {
+				var displacements [3][3]float32
+				for a, values := range target {
+					if values != nil {
+						displacements[a] = [3]float32(vec3(values, v))
+					}
+				}
+				targets[t].Positions = append(targets[t].Positions, displacements[0])
+				if hasNormals {
+					targets[t].Normals = append(targets[t].Normals, displacements[1])
+				}
+				if hasTangents {
+					targets[t].Tangents = append(targets[t].Tangents, displacements[2])
+				}
+			}
+		}
+
+		indices := make([]uint32, len(prim.indices))
+		for i, index := range prim.indices {
+			indices[i] = base + index
+		}
+
+		switch prim.mode {
+		case modePoints:
+			model.PointIndices = append(model.PointIndices, indices...)
+		case modeLines, modeLineLoop, modeLineStrip:
+			model.LineIndices = append(model.LineIndices, lineList(indices, prim.mode)...)
+		default:
+			triangles := triangleList(indices, prim.mode)
+			materialName := ""
+			if prim.material != -1 {
+				materialName = materials[prim.material].Name
+			}
+			model.Submeshes = append(model.Submeshes, objloader.Submesh{
+				MaterialName: materialName,
+				IndexOffset:  uint32(len(model.Indices)),
+				IndexCount:   uint32(len(triangles)),
+			})
+			model.Indices = append(model.Indices, triangles...)
+		}
+	}
+
+	return model, targets, nil
+}
+
+func (l *loader) primitive(p gltfPrimitive, numMaterials int) (primitive, error) {
+	prim := primitive{mode: modeTriangles, material: -1}
+	if p.Mode != nil {
+		if *p.Mode < modePoints || *p.Mode > modeTriangleFan {
+			return prim, fmt.Errorf("unknown mode %d", *p.Mode)
+		}
+		prim.mode = *p.Mode
+	}
+	if p.Material != nil {
+		if *p.Material < 0 || *p.Material >= numMaterials {
+			return prim, fmt.Errorf("material %d out of range", *p.Material)
+		}
+		prim.material = *p.Material
+	}
+
+	position, ok := p.Attributes["POSITION"]
+	if !ok {
+		return prim, fmt.Errorf("missing POSITION attribute")
+	}
+	var err error
+	if prim.positions, err = l.attribute(position, "POSITION", 3); err != nil {
+		return prim, err
+	}
+	count := len(prim.positions) / 3
+
+	attributes := []struct {
+		name       string
+		dst        *[]float32
+		components []int
+	}{
+		{"NORMAL", &prim.normals, []int{3}},
+		{"TEXCOORD_0", &prim.texCoords, []int{2}},
+		{"COLOR_0", &prim.colors, []int{3, 4}},
+		{"TANGENT", &prim.tangents, []int{4}},
+		{"JOINTS_0", &prim.joints, []int{4}},
+		{"WEIGHTS_0", &prim.weights, []int{4}},
+	}
+	for _, attr := range attributes {
+		accessor, ok := p.Attributes[attr.name]
+		if !ok {
+			continue
+		}
+		if *attr.dst, err = l.attribute(accessor, attr.name, attr.components...); err != nil {
+			return prim, err
+		}
+		if len(*attr.dst)/l.components(accessor) != count {
+			return prim, fmt.Errorf("%s has %d elements, POSITION %d", attr.name, len(*attr.dst)/l.components(accessor), count)
+		}
+	}
+	if prim.colors != nil {
+		prim.colorComponents = l.components(p.Attributes["COLOR_0"])
+	}
+	if (prim.joints == nil) != (prim.weights == nil) {
+		return prim, fmt.Errorf("JOINTS_0 without WEIGHTS_0 or vice versa")
+	}
+	for _, joint := range prim.joints {
+		if joint < 0 || joint > math.MaxUint16 {
+			return prim, fmt.Errorf("joint %v out of range", joint)
+		}
+	}
+
+	for t, target := range p.Targets {
+		var displacements [3][]float32
+		for a, name := range []string{"POSITION", "NORMAL", "TANGENT"} {
+			accessor, ok := target[name]
+			if !ok {
+				continue
+			}
+			if displacements[a], err = l.attribute(accessor, name, 3); err != nil {
+				return prim, fmt.Errorf("morph target %d: %w", t, err)
+			}
+			if len(displacements[a])/3 != count {
+				return prim, fmt.Errorf("morph target %d: %s has %d elements, POSITION %d", t, name, len(displacements[a])/3, count)
+			}
+		}
+		prim.targets = append(prim.targets, displacements)
+	}
+
+	if p.Indices != nil {
+		if prim.indices, err = l.readIndices(*p.Indices); err != nil {
+			return prim, fmt.Errorf("indices: %w", err)
+		}
+		for _, index := range prim.indices {
+			if int64(index) >= int64(count) {
+				return prim, fmt.Errorf("index %d out of range", index)
+			}
+		}
+	} else {
+		prim.indices = make([]uint32, count)
+		for i := range prim.indices {
+			prim.indices[i] = uint32(i)
+		}
+	}
+
+	return prim, nil
+}
+
+// attribute reads accessor i of the named attribute, which must have
+// one of the given numbers of components.
+func (l *loader) attribute(i int, name string, components ...int) ([]float32, error) {
+	values, n, err := l.readFloats(i)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, c := range components {
+		if n == c && l.doc.Accessors[i].Type != "MAT2" {
+			return values, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: invalid type %s", name, l.doc.Accessors[i].Type)
+}
+
+func (l *loader) components(i int) int {
+	n, _ := typeSize(l.doc.Accessors[i].Type)
+	return n
+}
+
+func vec3(values []float32, i int) glm.Vec3[float32] {
+	return glm.Vec3[float32]{values[i*3], values[i*3+1], values[i*3+2]}
+}
+
+// triangleList converts strips and fans to a triangle list, dropping
+// incomplete triangles.
+func triangleList(indices []uint32, mode int) []uint32 {
+	switch mode {
+	case modeTriangleStrip:
+		triangles := []uint32{}
+		for i := 2; i < len(indices); i++ {
+			// every other triangle is flipped to keep the winding
+			if i%2 == 0 {
+				triangles = append(triangles, indices[i-2], indices[i-1], indices[i])
+			} else {
+				triangles = append(triangles, indices[i-1], indices[i-2], indices[i])
+			}
+		}
+		return triangles
+	case modeTriangleFan:
+		triangles := []uint32{}
+		for i := 2; i < len(indices); i++ {
+			triangles = append(triangles, indices[i-1], indices[i], indices[0])
+		}
+		return triangles
+	}
+	return indices[:len(indices)/3*3]
+}
+
+// lineList converts strips and loops to a line list.
+func lineList(indices []uint32, mode int) []uint32 {
+	if mode == modeLines {
+		return indices[:len(indices)/2*2]
+	}
+	lines := []uint32{}
+	for i := 1; i < len(indices); i++ {
+		lines = append(lines, indices[i-1], indices[i])
+	}
+	if mode == modeLineLoop && len(indices) > 1 {
+		lines = append(lines, indices[len(indices)-1], indices[0])
+	}
+	return lines
+}
+
+// smoothNormals returns area weighted vertex normals of the triangles
+// of prim. glTF asks for flat normals, but those would need the
+// vertices to be split.
+func smoothNormals(prim primitive) []float32 {
+	normals := make([]glm.Vec3[float32], len(prim.positions)/3)
+	if prim.mode >= modeTriangles {
+		triangles := triangleList(prim.indices, prim.mode)
+		for i := 0; i < len(triangles); i += 3 {
+			a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+			pa, pb, pc := vec3(prim.positions, int(a)), vec3(prim.positions, int(b)), vec3(prim.positions, int(c))
+			n := pb.Sub(pa).Cross(pc.Sub(pa))
+			normals[a] = normals[a].Add(n)
+			normals[b] = normals[b].Add(n)
+			normals[c] = normals[c].Add(n)
+		}
+	}
+
+	out := make([]float32, 0, len(normals)*3)
+	for _, n := range normals {
+		if n.Magnitude() > 0 {
+			n = n.Normalize()
+		}
+		out = append(out, n[0], n[1], n[2])
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package gltfloader
+
+import (
+	"fmt"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// Skin is a set of joints deforming a mesh, the Joints of a mesh's
+// vertices index Joints.
+type Skin struct {
+	Name   string
+	Joints []int
+	// InverseBindMatrices transform the mesh into the space of each
+	// joint.
+	InverseBindMatrices []glm.Mat4[float32]
+}
+
+func (l *loader) skin(s gltfSkin, numNodes int) (Skin, error) {
+	skin := Skin{Name: s.Name, Joints: s.Joints}
+	for _, j := range s.Joints {
+		if j < 0 || j >= numNodes {
+			return skin, fmt.Errorf("joint %d out of range", j)
+		}
+	}
+
+	skin.InverseBindMatrices = make([]glm.Mat4[float32], len(s.Joints))
+	if s.InverseBindMatrices == nil {
+		for i := range skin.InverseBindMatrices {
+			skin.InverseBindMatrices[i] = glm.Mat4Identity[float32]()
+		}
+		return skin, nil
+	}
+
+	values, n, err := l.readFloats(*s.InverseBindMatrices)
+	if err != nil {
+		return skin, fmt.Errorf("inverse bind matrices: %w", err)
+	}
+	if n != 16 || len(values) != len(s.Joints)*16 {
+		return skin, fmt.Errorf("inverse bind matrices: want %d MAT4", len(s.Joints))
+	}
+	for i := range skin.InverseBindMatrices {
+		skin.InverseBindMatrices[i] = glm.Mat4[float32](values[i*16 : i*16+16])
+	}
+	return skin, nil
+}
+
+// JointMatrices appends the joint matrices of all skins to dst, in
+// skin order, and returns the extended slice. A joint matrix is the
+// world matrix of the joint times its inverse bind matrix, so it
+// moves the vertices of a skinned mesh in the current pose.
+func (d *Document) JointMatrices(dst []glm.Mat4[float32]) []glm.Mat4[float32] {
+	for _, s := range d.Skins {
+		for i, j := range s.Joints {
+			dst = append(dst, d.Nodes[j].Transform.WorldMatrix().Mul4(s.InverseBindMatrices[i]))
+		}
+	}
+	return dst
+}
+
+// checkJoints returns an error if a vertex of m is weighted to a joint
+// beyond the numJoints of its skin.
+func checkJoints(m objloader.Model, numJoints int) error {
+	for v, joints := range m.Joints {
+		for c, j := range joints {
+			if m.Weights[v][c] != 0 && int(j) >= numJoints {
+				return fmt.Errorf("vertex %d: joint %d out of range", v, j)
+			}
+		}
+	}
+	return nil
+}
+
+// jointOffset returns the index of the first joint matrix of skin in
+// the joint matrices returned by JointMatrices.
+func (d *Document) jointOffset(skin int) int {
+	offset := 0
+	for _, s := range d.Skins[:skin] {
+		offset += len(s.Joints)
+	}
+	return offset
+}
+
+// MorphTarget holds displacements of the vertices of a mesh, they are
+// added scaled by the morph weights of its node.
+type MorphTarget struct {
+	Positions [][3]float32
+	// Normals and Tangents are empty if the mesh has none.
+	Normals  [][3]float32
+	Tangents [][3]float32
+}
+
+// Morph returns a copy of m with its vertices, normals and tangents
+// displaced by targets, which must belong to m, scaled by weights.
+func Morph(m *objloader.Model, targets []MorphTarget, weights []float32) objloader.Model {
+	out := *m
+	out.Vertices = append([][3]float32(nil), m.Vertices...)
+	out.Normals = append([][3]float32(nil), m.Normals...)
+	out.Tangents = append([][4]float32(nil), m.Tangents...)
+
+	for t, target := range targets {
+		if t >= len(weights) || weights[t] == 0 {
+			continue
+		}
+		w := weights[t]
+		for v, d := range target.Positions {
+			out.Vertices[v] = glm.Vec3[float32](out.Vertices[v]).Add(glm.Vec3[float32](d).MulScalar(w))
+		}
+		for v, d := range target.Normals {
+			out.Normals[v] = glm.Vec3[float32](out.Normals[v]).Add(glm.Vec3[float32](d).MulScalar(w))
+		}
+		for v, d := range target.Tangents {
+			tangent := glm.Vec3[float32]{out.Tangents[v][0], out.Tangents[v][1], out.Tangents[v][2]}.Add(glm.Vec3[float32](d).MulScalar(w))
+			out.Tangents[v] = [4]float32{tangent[0], tangent[1], tangent[2], out.Tangents[v][3]}
+		}
+	}
+
+	for v, n := range out.Normals {
+		if n := glm.Vec3[float32](n); n.Magnitude() > 0 {
+			out.Normals[v] = n.Normalize()
+		}
+	}
+	for v, t := range out.Tangents {
+		if tangent := (glm.Vec3[float32]{t[0], t[1], t[2]}); tangent.Magnitude() > 0 {
+			tangent = tangent.Normalize()
+			out.Tangents[v] = [4]float32{tangent[0], tangent[1], tangent[2], t[3]}
+		}
+	}
+	return out
+}
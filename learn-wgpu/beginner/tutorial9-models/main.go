@@ -3,13 +3,19 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"math"
+	"os"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/gamen/dpi"
 	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/animation"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/scene"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -18,12 +24,13 @@ var shaderCode string
 
 const NumInstancesPerRow = 10
 
-var OpenGlToWgpuMatrix = glm.Mat4[float32]{
-	1.0, 0.0, 0.0, 0.0,
-	0.0, 1.0, 0.0, 0.0,
-	0.0, 0.0, 0.5, 0.0,
-	0.0, 0.0, 0.5, 1.0,
-}
+// cpuSkinning skins glTF models on the CPU instead of in the vertex
+// shader, see Skeleton.
+var cpuSkinning = os.Getenv("TUTORIAL9_CPU_SKINNING") == "1"
+
+// MaxLodPixelError is the largest on screen error, in pixels, of the
+// level of detail an instance is drawn at.
+const MaxLodPixelError = 1
 
 type Camera struct {
 	eye     glm.Vec3[float32]
@@ -37,7 +44,7 @@ type Camera struct {
 
 func (c *Camera) buildViewProjectionMatrix() glm.Mat4[float32] {
 	view := glm.LookAtRH(c.eye, c.target, c.up)
-	proj := glm.Perspective(c.fovYRad, c.aspect, c.znear, c.zfar)
+	proj := glm.PerspectiveReverseZRH(c.fovYRad, c.aspect, c.znear, c.zfar)
 	return proj.Mul4(view)
 }
 
@@ -57,7 +64,7 @@ func NewCameraUnifrom() *CameraUniform {
 }
 
 func (c *CameraUniform) UpdateViewProj(camera *Camera) {
-	c.viewProj = OpenGlToWgpuMatrix.Mul4(camera.buildViewProjectionMatrix())
+	c.viewProj = camera.buildViewProjectionMatrix()
 }
 
 type CameraController struct {
@@ -99,19 +106,73 @@ func (c *CameraController) UpdateCamera(camera *Camera) {
 	}
 }
 
+// Instance is a node of the instance hierarchy, it is drawn with the
+// world matrix of the node.
 type Instance struct {
-	position glm.Vec3[float32]
-	rotation glm.Quaternion[float32]
+	node *scene.Node[float32]
+
+	positionTrack *animation.Track[float32, glm.Vec3[float32]]
+	rotationTrack *animation.Track[float32, glm.Quaternion[float32]]
+	// phase offsets the time the tracks are sampled at
+	phase float32
+}
+
+// NewInstance creates an instance bobbing up and down above the
+// translation of node while spinning around its rotated up axis.
+func NewInstance(node *scene.Node[float32], phase float32) Instance {
+	position, rotation := node.Translation(), node.Rotation()
+
+	positionTrack := animation.NewVec3Track([]animation.Keyframe[float32, glm.Vec3[float32]]{
+		{Time: 0, Value: position},
+		{Time: 1, Value: position.Add(glm.Vec3[float32]{0, 0.5, 0})},
+	}, animation.InterpolationLinear)
+	positionTrack.Mode = animation.ModePingPong
+	positionTrack.Easing = animation.EaseInOutSine[float32]
+
+	// a full turn is split in thirds, slerp takes the shortest arc
+	// between keyframes
+	spin := make([]animation.Keyframe[float32, glm.Quaternion[float32]], 4)
+	for i := range spin {
+		angle := glm.DegToRad(120 * float32(i))
+		spin[i] = animation.Keyframe[float32, glm.Quaternion[float32]]{
+			Time:  2 * float32(i),
+			Value: rotation.Mul(glm.QuaternionFromAxisAngle(glm.Vec3[float32]{0, 1, 0}, angle)),
+		}
+	}
+	rotationTrack := animation.NewQuaternionTrack(spin, animation.InterpolationLinear)
+	rotationTrack.Mode = animation.ModeLoop
+
+	return Instance{
+		node:          node,
+		positionTrack: positionTrack,
+		rotationTrack: rotationTrack,
+		phase:         phase,
+	}
+}
+
+// Animate sets the translation and rotation of the node to the
+// values of the tracks at time t, in seconds.
+func (i Instance) Animate(t float32) {
+	i.node.SetTranslation(i.positionTrack.Sample(t + i.phase))
+	i.node.SetRotation(i.rotationTrack.Sample(t + i.phase))
 }
 
 func (i Instance) ToRaw() InstanceRaw {
+	return NewInstanceRaw(i.node.WorldMatrix())
+}
+
+// NewInstanceRaw creates the per-instance data from a model matrix,
+// e.g. the world matrix of a scene.Node.
+func NewInstanceRaw(model glm.Mat4[float32]) InstanceRaw {
 	return InstanceRaw{
-		model: glm.Mat4FromTranslation(i.position).Mul4(glm.Mat4FromQuaternion(i.rotation)),
+		model:  model,
+		normal: model.NormalMatrix(),
 	}
 }
 
 type InstanceRaw struct {
-	model glm.Mat4[float32]
+	model  glm.Mat4[float32]
+	normal glm.Mat3[float32]
 }
 
 var InstanceBufferLayout = wgpu.VertexBufferLayout{
@@ -138,26 +199,57 @@ var InstanceBufferLayout = wgpu.VertexBufferLayout{
 			ShaderLocation: 8,
 			Format:         wgpu.VertexFormat_Float32x4,
 		},
+		{
+			Offset:         wgpu.VertexFormat_Float32x4.Size() * 4,
+			ShaderLocation: 9,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
+		{
+			Offset:         wgpu.VertexFormat_Float32x4.Size()*4 + wgpu.VertexFormat_Float32x3.Size(),
+			ShaderLocation: 10,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
+		{
+			Offset:         wgpu.VertexFormat_Float32x4.Size()*4 + wgpu.VertexFormat_Float32x3.Size()*2,
+			ShaderLocation: 11,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
 	},
 }
 
+// ClearColor is sRGB encoded.
+var ClearColor = glm.Color[float64]{R: 0.1, G: 0.2, B: 0.3, A: 1.0}
+
 type State struct {
 	surface          *wgpu.Surface
 	swapChain        *wgpu.SwapChain
 	device           *wgpu.Device
 	queue            *wgpu.Queue
 	config           *wgpu.SwapChainDescriptor
+	clearColor       wgpu.Color
 	size             dpi.PhysicalSize[uint32]
 	renderPipeline   *wgpu.RenderPipeline
+	linePipeline     *wgpu.RenderPipeline
+	pointPipeline    *wgpu.RenderPipeline
 	objModel         *Model
+	skeleton         *Skeleton
+	startTime        time.Time
+	lastUpdate       time.Time
 	camera           *Camera
 	cameraController *CameraController
 	cameraUniform    *CameraUniform
 	cameraBuffer     *wgpu.Buffer
 	cameraBindGroup  *wgpu.BindGroup
+	instanceRoot     *scene.Node[float32]
 	instances        [NumInstancesPerRow * NumInstancesPerRow]Instance
 	instanceBuffer   *wgpu.Buffer
-	depthTexture     *Texture
+	visibleInstances uint32
+	lodInstances     []InstanceRange
+	cursorPosition   glm.Vec2[float32]
+	// pickedInstance is the index of the last clicked instance, -1 if
+	// none has been clicked yet.
+	pickedInstance int
+	depthTexture   *Texture
 }
 
 func InitState(window display.Window) (s *State, err error) {
@@ -167,7 +259,7 @@ func InitState(window display.Window) (s *State, err error) {
 			s = nil
 		}
 	}()
-	s = &State{}
+	s = &State{pickedInstance: -1}
 
 	s.size = window.InnerSize()
 
@@ -202,6 +294,8 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.clearColor = wgpu.Color(ClearColor.ForFormat(s.config.Format))
+
 	textureBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
 		Entries: []wgpu.BindGroupLayoutEntry{
 			{
@@ -250,6 +344,7 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
+	s.instanceRoot = scene.NewNode[float32]("Instances")
 	s.instances = [NumInstancesPerRow * NumInstancesPerRow]Instance{}
 	{
 		const SpaceBetween = 3.0
@@ -269,23 +364,23 @@ func InitState(window display.Window) (s *State, err error) {
 					rotation = glm.QuaternionFromAxisAngle(position.Normalize(), glm.DegToRad[float32](45))
 				}
 
-				s.instances[index] = Instance{
-					position: position,
-					rotation: rotation,
+				node := scene.NewNode[float32](fmt.Sprintf("Instance %d", index))
+				node.SetTranslation(position)
+				node.SetRotation(rotation)
+				if err := s.instanceRoot.AddChild(node); err != nil {
+					return s, err
 				}
+
+				s.instances[index] = NewInstance(node, 0.1*(x+z))
 				index++
 			}
 		}
 	}
 
-	var instanceData [NumInstancesPerRow * NumInstancesPerRow]InstanceRaw
-	for i, v := range s.instances {
-		instanceData[i] = v.ToRaw()
-	}
-	s.instanceBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-		Label:    "Instance Buffer",
-		Contents: wgpu.ToBytes(instanceData[:]),
-		Usage:    wgpu.BufferUsage_Vertex,
+	s.instanceBuffer, err = s.device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Instance Buffer",
+		Size:  uint64(len(s.instances)) * uint64(unsafe.Sizeof(InstanceRaw{})),
+		Usage: wgpu.BufferUsage_Vertex | wgpu.BufferUsage_CopyDst,
 	})
 	if err != nil {
 		return s, err
@@ -320,10 +415,33 @@ func InitState(window display.Window) (s *State, err error) {
 		return s, err
 	}
 
-	s.objModel, err = LoadModel(s.device, s.queue, textureBindGroupLayout)
+	s.objModel, err = LoadModel(s.device, s.queue, textureBindGroupLayout, s.config.Format)
 	if err != nil {
 		return s, err
 	}
+	s.updateVisibleInstances()
+
+	jointBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Label: "JointBindGroupLayout",
+		Entries: []wgpu.BindGroupLayoutEntry{{
+			Binding:    0,
+			Visibility: wgpu.ShaderStage_Vertex,
+			Buffer: wgpu.BufferBindingLayout{
+				Type: wgpu.BufferBindingType_ReadOnlyStorage,
+			},
+		}},
+	})
+	if err != nil {
+		return s, err
+	}
+	defer jointBindGroupLayout.Release()
+
+	s.skeleton, err = NewSkeleton(s.device, jointBindGroupLayout, s.objModel, cpuSkinning)
+	if err != nil {
+		return s, err
+	}
+	s.startTime = time.Now()
+	s.lastUpdate = s.startTime
 
 	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
 		Label: "shader.wgsl",
@@ -344,7 +462,7 @@ func InitState(window display.Window) (s *State, err error) {
 	renderPipelineLayout, err := s.device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
 		Label: "Render Pipeline Layout",
 		BindGroupLayouts: []*wgpu.BindGroupLayout{
-			textureBindGroupLayout, cameraBindGroupLayout,
+			textureBindGroupLayout, cameraBindGroupLayout, jointBindGroupLayout,
 		},
 	})
 	if err != nil {
@@ -352,9 +470,37 @@ func InitState(window display.Window) (s *State, err error) {
 	}
 	defer renderPipelineLayout.Release()
 
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
+	s.renderPipeline, err = createRenderPipeline(s.device, renderPipelineLayout, shader, s.config.Format, wgpu.PrimitiveTopology_TriangleList)
+	if err != nil {
+		return s, err
+	}
+	s.linePipeline, err = createRenderPipeline(s.device, renderPipelineLayout, shader, s.config.Format, wgpu.PrimitiveTopology_LineList)
+	if err != nil {
+		return s, err
+	}
+	s.pointPipeline, err = createRenderPipeline(s.device, renderPipelineLayout, shader, s.config.Format, wgpu.PrimitiveTopology_PointList)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// createRenderPipeline creates the pipeline drawing models with the
+// given topology. Lines and points are drawn unculled and coloured by
+// their vertex colours only.
+func createRenderPipeline(device *wgpu.Device, layout *wgpu.PipelineLayout, shader *wgpu.ShaderModule, format wgpu.TextureFormat, topology wgpu.PrimitiveTopology) (*wgpu.RenderPipeline, error) {
+	label, fragmentEntryPoint, cullMode := "Render Pipeline", "fs_main", wgpu.CullMode_Back
+	switch topology {
+	case wgpu.PrimitiveTopology_LineList:
+		label, fragmentEntryPoint, cullMode = "Line Render Pipeline", "fs_color", wgpu.CullMode_None
+	case wgpu.PrimitiveTopology_PointList:
+		label, fragmentEntryPoint, cullMode = "Point Render Pipeline", "fs_color", wgpu.CullMode_None
+	}
+
+	return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+		Label:  label,
+		Layout: layout,
 		Vertex: wgpu.VertexState{
 			Module:     shader,
 			EntryPoint: "vs_main",
@@ -362,22 +508,22 @@ func InitState(window display.Window) (s *State, err error) {
 		},
 		Fragment: &wgpu.FragmentState{
 			Module:     shader,
-			EntryPoint: "fs_main",
+			EntryPoint: fragmentEntryPoint,
 			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
+				Format:    format,
 				Blend:     &wgpu.BlendState_Replace,
 				WriteMask: wgpu.ColorWriteMask_All,
 			}},
 		},
 		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
+			Topology:  topology,
 			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
+			CullMode:  cullMode,
 		},
 		DepthStencil: &wgpu.DepthStencilState{
 			Format:            DepthTextureFormat,
 			DepthWriteEnabled: true,
-			DepthCompare:      wgpu.CompareFunction_Less,
+			DepthCompare:      wgpu.CompareFunction_Greater,
 			StencilFront: wgpu.StencilFaceState{
 				Compare: wgpu.CompareFunction_Always,
 			},
@@ -391,17 +537,109 @@ func InitState(window display.Window) (s *State, err error) {
 			AlphaToCoverageEnabled: false,
 		},
 	})
-	if err != nil {
-		return s, err
-	}
-
-	return s, nil
 }
 
 func (s *State) Update() {
+	now := time.Now()
+	if err := s.skeleton.Update(s.queue, float32(now.Sub(s.lastUpdate).Seconds())); err != nil {
+		fmt.Println("error occured while skinning:", err)
+	}
+	s.lastUpdate = now
+
+	t := float32(now.Sub(s.startTime).Seconds())
+	for _, v := range s.instances {
+		v.Animate(t)
+	}
+
 	s.cameraController.UpdateCamera(s.camera)
 	s.cameraUniform.UpdateViewProj(s.camera)
 	s.queue.WriteBuffer(s.cameraBuffer, 0, wgpu.ToBytes(s.cameraUniform.viewProj[:]))
+	s.updateVisibleInstances()
+}
+
+// updateVisibleInstances writes only the instances whose bounds
+// intersect the camera frustum to the front of the instance buffer,
+// grouped by the level of detail they are drawn at.
+func (s *State) updateVisibleInstances() {
+	frustum := glm.FrustumFromMat4(s.cameraUniform.viewProj)
+
+	// an error of 1 world unit at distance d covers pixelsPerUnit/d
+	// pixels
+	pixelsPerUnit := float32(s.size.Height) / 2 / float32(math.Tan(float64(s.camera.fovYRad)/2))
+
+	var (
+		visibleData [NumInstancesPerRow * NumInstancesPerRow]InstanceRaw
+		levels      [NumInstancesPerRow * NumInstancesPerRow]int
+	)
+	lodInstances := make([]InstanceRange, len(s.objModel.LodErrors)+1)
+	visible := 0
+	for _, v := range s.instances {
+		raw := v.ToRaw()
+		bounds := s.objModel.Bounds.Transform(raw.model)
+		if !frustum.IntersectsAABB(bounds) {
+			continue
+		}
+
+		// the coarsest level whose error is below a pixel
+		distance := bounds.Center().Distance(s.camera.eye)
+		level := 0
+		for i, lodError := range s.objModel.LodErrors {
+			if lodError*pixelsPerUnit > MaxLodPixelError*distance {
+				break
+			}
+			level = i + 1
+		}
+
+		visibleData[visible] = raw
+		levels[visible] = level
+		lodInstances[level].Count++
+		visible++
+	}
+
+	for level := 1; level < len(lodInstances); level++ {
+		lodInstances[level].First = lodInstances[level-1].First + lodInstances[level-1].Count
+	}
+	var instanceData [NumInstancesPerRow * NumInstancesPerRow]InstanceRaw
+	next := make([]uint32, len(lodInstances))
+	for i, raw := range visibleData[:visible] {
+		level := levels[i]
+		instanceData[lodInstances[level].First+next[level]] = raw
+		next[level]++
+	}
+
+	s.visibleInstances = uint32(visible)
+	s.lodInstances = lodInstances
+	if visible > 0 {
+		s.queue.WriteBuffer(s.instanceBuffer, 0, wgpu.ToBytes(instanceData[:visible]))
+	}
+}
+
+// PickInstance returns the index of the nearest instance
+// under the window point (x, y) in pixels.
+func (s *State) PickInstance(x, y float32) (instance int, ok bool) {
+	invViewProj, invertible := s.cameraUniform.viewProj.Inverse()
+	if !invertible {
+		return 0, false
+	}
+	ray := glm.RayFromScreen(x, y, float32(s.size.Width), float32(s.size.Height), invViewProj)
+
+	var nearest float32
+	for i, v := range s.instances {
+		model := v.ToRaw().model
+		if _, hit := ray.IntersectAABB(s.objModel.Bounds.Transform(model)); !hit {
+			continue
+		}
+
+		invModel, invertible := model.Inverse()
+		if !invertible {
+			continue
+		}
+		_, _, t, hit := objloader.Pick(s.objModel.ObjModels, ray.Transform(invModel))
+		if hit && (!ok || t < nearest) {
+			instance, nearest, ok = i, t, true
+		}
+	}
+	return instance, ok
 }
 
 func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
@@ -445,19 +683,14 @@ func (s *State) Render() error {
 
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
-			View:   view,
-			LoadOp: wgpu.LoadOp_Clear,
-			ClearValue: wgpu.Color{
-				R: 0.1,
-				G: 0.2,
-				B: 0.3,
-				A: 1.0,
-			},
-			StoreOp: wgpu.StoreOp_Store,
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			ClearValue: s.clearColor,
+			StoreOp:    wgpu.StoreOp_Store,
 		}},
 		DepthStencilAttachment: &wgpu.RenderPassDepthStencilAttachment{
 			View:              s.depthTexture.view,
-			DepthClearValue:   1,
+			DepthClearValue:   0,
 			DepthLoadOp:       wgpu.LoadOp_Clear,
 			DepthStoreOp:      wgpu.StoreOp_Store,
 			DepthReadOnly:     false,
@@ -470,8 +703,13 @@ func (s *State) Render() error {
 	defer renderPass.Release()
 
 	renderPass.SetVertexBuffer(1, s.instanceBuffer, 0, wgpu.WholeSize)
+	renderPass.SetBindGroup(2, s.skeleton.BindGroup, nil)
 	renderPass.SetPipeline(s.renderPipeline)
-	drawModelInstanced(renderPass, s.objModel, s.cameraBindGroup, uint32(len(s.instances)))
+	drawModelInstanced(renderPass, s.objModel, s.cameraBindGroup, s.lodInstances)
+	renderPass.SetPipeline(s.linePipeline)
+	drawModelPrimitivesInstanced(renderPass, s.objModel, wgpu.PrimitiveTopology_LineList, s.cameraBindGroup, s.visibleInstances)
+	renderPass.SetPipeline(s.pointPipeline)
+	drawModelPrimitivesInstanced(renderPass, s.objModel, wgpu.PrimitiveTopology_PointList, s.cameraBindGroup, s.visibleInstances)
 	renderPass.End()
 
 	cmdBuffer, err := encoder.Finish(nil)
@@ -491,10 +729,22 @@ func (s *State) Destroy() {
 		s.renderPipeline.Release()
 		s.renderPipeline = nil
 	}
+	if s.linePipeline != nil {
+		s.linePipeline.Release()
+		s.linePipeline = nil
+	}
+	if s.pointPipeline != nil {
+		s.pointPipeline.Release()
+		s.pointPipeline = nil
+	}
 	if s.depthTexture != nil {
 		s.depthTexture.Destroy()
 		s.depthTexture = nil
 	}
+	if s.skeleton != nil {
+		s.skeleton.Destroy()
+		s.skeleton = nil
+	}
 	if s.objModel != nil {
 		s.objModel.Destroy()
 		s.objModel = nil
@@ -567,6 +817,21 @@ func main() {
 		})
 	})
 
+	w.SetCursorMovedCallback(func(physicalX, physicalY float64) {
+		s.cursorPosition = glm.Vec2[float32]{float32(physicalX), float32(physicalY)}
+	})
+
+	w.SetMouseInputCallback(func(state events.ButtonState, button events.MouseButton) {
+		if state != events.ButtonStatePressed || button != events.MouseButtonLeft {
+			return
+		}
+
+		if i, ok := s.PickInstance(s.cursorPosition[0], s.cursorPosition[1]); ok && i != s.pickedInstance {
+			s.pickedInstance = i
+			w.SetTitle(fmt.Sprintf("tutorial9-models: instance %d", i))
+		}
+	})
+
 	w.SetKeyboardInputCallback(func(state events.ButtonState, scanCode events.ScanCode, virtualKeyCode events.VirtualKey) {
 		isPressed := state == events.ButtonStatePressed
 
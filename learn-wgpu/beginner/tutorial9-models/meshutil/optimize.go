@@ -0,0 +1,431 @@
+package meshutil
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// CacheSize is the size of the FIFO post-transform vertex cache
+// simulated by AnalyzeVertexCache and Optimize.
+const CacheSize = 16
+
+// MaxUint16Vertices is the largest vertex count whose indices are
+// compacted by CompactIndices, meshes need fewer than 65536 vertices
+// to be drawn with 16-bit indices.
+const MaxUint16Vertices = 1<<16 - 1
+
+// CacheStats describes how well a triangle list uses the
+// post-transform vertex cache.
+type CacheStats struct {
+	// VerticesTransformed is the number of cache misses.
+	VerticesTransformed int
+	// ACMR is the average cache miss ratio, the number of vertices
+	// transformed per triangle, between 0.5 and 3 with lower being
+	// better.
+	ACMR float64
+	// ATVR is the average transformed vertex ratio, the number of
+	// vertices transformed per referenced vertex, 1 at best.
+	ATVR float64
+}
+
+// AnalyzeVertexCache simulates a FIFO vertex cache of cacheSize
+// entries drawing the triangle list indices.
+func AnalyzeVertexCache(indices []uint32, vertexCount int, cacheSize int) (stats CacheStats) {
+	// a vertex is in the cache if it was transformed less than
+	// cacheSize misses ago
+	timestamps := make([]int, vertexCount)
+	referenced := 0
+	for _, v := range indices {
+		if timestamps[v] == 0 {
+			referenced++
+		} else if stats.VerticesTransformed+1-timestamps[v] <= cacheSize {
+			continue
+		}
+		stats.VerticesTransformed++
+		timestamps[v] = stats.VerticesTransformed
+	}
+
+	if triangles := len(indices) / 3; triangles != 0 {
+		stats.ACMR = float64(stats.VerticesTransformed) / float64(triangles)
+	}
+	if referenced != 0 {
+		stats.ATVR = float64(stats.VerticesTransformed) / float64(referenced)
+	}
+	return
+}
+
+// Optimize reorders the triangles of every submesh of m for the
+// vertex cache and then for overdraw, and finally the vertices of m
+// in the order they are first referenced. It returns the cache
+// statistics of m.Indices before and after.
+func Optimize(m *objloader.Model) (before, after CacheStats) {
+	before = AnalyzeVertexCache(m.Indices, len(m.Vertices), CacheSize)
+
+	ranges := m.Submeshes
+	if len(ranges) == 0 {
+		ranges = []objloader.Submesh{{IndexCount: uint32(len(m.Indices))}}
+	}
+	for _, r := range ranges {
+		indices := m.Indices[r.IndexOffset : r.IndexOffset+r.IndexCount]
+		OptimizeVertexCache(indices, len(m.Vertices))
+		OptimizeOverdraw(indices, m.Vertices, 1.05)
+	}
+	OptimizeVertexFetch(m)
+
+	after = AnalyzeVertexCache(m.Indices, len(m.Vertices), CacheSize)
+	return
+}
+
+// Scoring parameters of Forsyth's algorithm, from "Linear-Speed
+// Vertex Cache Optimisation".
+const (
+	forsythCacheSize         = 32
+	forsythCacheDecayPower   = 1.5
+	forsythLastTriScore      = 0.75
+	forsythValenceBoostScale = 2
+	forsythValenceBoostPower = 0.5
+)
+
+// forsythScore is the score of a vertex at cachePosition, -1 if not
+// cached, that is still used by remaining triangles.
+func forsythScore(cachePosition int, remaining uint32) float32 {
+	if remaining == 0 {
+		return -1
+	}
+
+	var score float64
+	switch {
+	case cachePosition < 0:
+	case cachePosition < 3:
+		// the vertices of the last triangle get a fixed score so
+		// that strips aren't preferred over fans
+		score = forsythLastTriScore
+	default:
+		scaler := 1 / float64(forsythCacheSize-3)
+		score = math.Pow(1-float64(cachePosition-3)*scaler, forsythCacheDecayPower)
+	}
+
+	// boost vertices with few remaining triangles to get rid of them
+	score += forsythValenceBoostScale * math.Pow(float64(remaining), -forsythValenceBoostPower)
+	return float32(score)
+}
+
+// OptimizeVertexCache reorders the triangle list indices in place for
+// post-transform vertex cache efficiency using Forsyth's algorithm.
+// The order of the vertices within a triangle is kept.
+func OptimizeVertexCache(indices []uint32, vertexCount int) {
+	numTriangles := len(indices) / 3
+	if numTriangles < 2 {
+		return
+	}
+
+	// adjacency[offsets[v]:offsets[v]+remaining[v]] are the triangles
+	// using v that are yet to be emitted
+	remaining := make([]uint32, vertexCount)
+	for _, v := range indices[:numTriangles*3] {
+		remaining[v]++
+	}
+	offsets := make([]uint32, vertexCount)
+	var offset uint32
+	for v, n := range remaining {
+		offsets[v] = offset
+		offset += n
+	}
+	adjacency := make([]uint32, numTriangles*3)
+	{
+		fill := make([]uint32, vertexCount)
+		for i, v := range indices[:numTriangles*3] {
+			adjacency[offsets[v]+fill[v]] = uint32(i / 3)
+			fill[v]++
+		}
+	}
+
+	cachePositions := make([]int, vertexCount)
+	scores := make([]float32, vertexCount)
+	for v := range scores {
+		cachePositions[v] = -1
+		scores[v] = forsythScore(-1, remaining[v])
+	}
+	triangleScores := make([]float32, numTriangles)
+	for t := range triangleScores {
+		for _, v := range indices[t*3 : t*3+3] {
+			triangleScores[t] += scores[v]
+		}
+	}
+
+	var (
+		emitted = make([]bool, numTriangles)
+		out     = make([]uint32, 0, numTriangles*3)
+		cache   = make([]uint32, 0, forsythCacheSize+3)
+		next    = make([]uint32, 0, forsythCacheSize+3)
+		// cursor is where the search for a triangle continues once
+		// no cached vertex has triangles left
+		cursor = 0
+		best   = -1
+	)
+
+	for len(out) < numTriangles*3 {
+		if best < 0 {
+			for emitted[cursor] {
+				cursor++
+			}
+			best = cursor
+		}
+
+		triangle := indices[best*3 : best*3+3]
+		out = append(out, triangle...)
+		emitted[best] = true
+
+		for _, v := range triangle {
+			adjacent := adjacency[offsets[v] : offsets[v]+remaining[v]]
+			for i, t := range adjacent {
+				if t == uint32(best) {
+					adjacent[i] = adjacent[len(adjacent)-1]
+					break
+				}
+			}
+			remaining[v]--
+		}
+
+		// the emitted vertices move to the front of the LRU cache
+		next = append(next[:0], triangle...)
+		for _, v := range cache {
+			if v != triangle[0] && v != triangle[1] && v != triangle[2] {
+				next = append(next, v)
+			}
+		}
+		for i, v := range next {
+			if i >= forsythCacheSize {
+				cachePositions[v] = -1
+			} else {
+				cachePositions[v] = i
+			}
+		}
+
+		// rescore the cached and evicted vertices and their triangles
+		for _, v := range next {
+			score := forsythScore(cachePositions[v], remaining[v])
+			delta := score - scores[v]
+			scores[v] = score
+			for _, t := range adjacency[offsets[v] : offsets[v]+remaining[v]] {
+				triangleScores[t] += delta
+			}
+		}
+
+		if len(next) > forsythCacheSize {
+			next = next[:forsythCacheSize]
+		}
+		cache, next = next, cache
+
+		best = -1
+		bestScore := float32(-1)
+		for _, v := range cache {
+			for _, t := range adjacency[offsets[v] : offsets[v]+remaining[v]] {
+				if triangleScores[t] > bestScore {
+					best, bestScore = int(t), triangleScores[t]
+				}
+			}
+		}
+	}
+
+	copy(indices, out)
+}
+
+// OptimizeOverdraw reorders the triangle list indices in place so
+// that triangles likely to occlude others are drawn first, following
+// Sander et al. "Fast Triangle Reordering for Vertex Locality and
+// Reduced Overdraw". indices should already be optimized for the
+// vertex cache, they are split into clusters that are sorted as a
+// whole so that the ACMR of each grows by at most the factor
+// threshold.
+func OptimizeOverdraw(indices []uint32, positions [][3]float32, threshold float32) {
+	numTriangles := len(indices) / 3
+	if numTriangles < 2 {
+		return
+	}
+
+	clusters := overdrawClusters(indices[:numTriangles*3], len(positions), threshold)
+
+	var meshCentroid glm.Vec3[float32]
+	for _, v := range indices[:numTriangles*3] {
+		meshCentroid = meshCentroid.Add(positions[v])
+	}
+	meshCentroid = meshCentroid.DivScalar(float32(numTriangles * 3))
+
+	type cluster struct {
+		start, end int
+		// sortKey is large for clusters facing away from the center
+		// of the mesh
+		sortKey float32
+	}
+	sorted := make([]cluster, len(clusters))
+	for i, start := range clusters {
+		end := numTriangles
+		if i+1 < len(clusters) {
+			end = clusters[i+1]
+		}
+
+		var centroid, normal glm.Vec3[float32]
+		var area float32
+		for t := start; t < end; t++ {
+			a := glm.Vec3[float32](positions[indices[t*3]])
+			b := glm.Vec3[float32](positions[indices[t*3+1]])
+			c := glm.Vec3[float32](positions[indices[t*3+2]])
+
+			// the cross product's magnitude is twice the area
+			n := b.Sub(a).Cross(c.Sub(a))
+			w := n.Magnitude()
+			centroid = centroid.Add(a.Add(b).Add(c).MulScalar(w / 3))
+			normal = normal.Add(n)
+			area += w
+		}
+		if area != 0 {
+			centroid = centroid.DivScalar(area)
+		}
+		if normal.Magnitude() != 0 {
+			normal = normal.Normalize()
+		}
+
+		sorted[i] = cluster{start, end, centroid.Sub(meshCentroid).Dot(normal)}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].sortKey > sorted[j].sortKey })
+
+	out := make([]uint32, 0, numTriangles*3)
+	for _, c := range sorted {
+		out = append(out, indices[c.start*3:c.end*3]...)
+	}
+	copy(indices, out)
+}
+
+// overdrawClusters returns the first triangle of every cluster.
+// Clusters start at hard boundaries, where the cache simulation
+// misses all vertices of a triangle, and are split further at soft
+// boundaries once their ACMR is within threshold of the ACMR of the
+// whole hard cluster.
+func overdrawClusters(indices []uint32, vertexCount int, threshold float32) []int {
+	numTriangles := len(indices) / 3
+
+	timestamps := make([]int, vertexCount)
+	misses := 0
+	// simulate returns the number of misses of triangle t, a
+	// timestamp of 0 lets the cache be flushed by bumping misses
+	simulate := func(t int) int {
+		n := 0
+		for _, v := range indices[t*3 : t*3+3] {
+			if timestamps[v] == 0 || misses+1-timestamps[v] > CacheSize {
+				misses++
+				timestamps[v] = misses
+				n++
+			}
+		}
+		return n
+	}
+	flush := func() { misses += CacheSize + 1 }
+
+	var hard []int
+	for t := 0; t < numTriangles; t++ {
+		if simulate(t) == 3 {
+			hard = append(hard, t)
+		}
+	}
+	if len(hard) == 0 || hard[0] != 0 {
+		hard = append([]int{0}, hard...)
+	}
+
+	var clusters []int
+	for i, start := range hard {
+		end := numTriangles
+		if i+1 < len(hard) {
+			end = hard[i+1]
+		}
+
+		flush()
+		clusterMisses := 0
+		for t := start; t < end; t++ {
+			clusterMisses += simulate(t)
+		}
+		limit := float32(clusterMisses) / float32(end-start) * threshold
+
+		flush()
+		clusters = append(clusters, start)
+		subMisses, subStart := 0, start
+		for t := start; t < end; t++ {
+			subMisses += simulate(t)
+			if t+1 < end && float32(subMisses)/float32(t+1-subStart) <= limit {
+				// the cluster so far is good enough, start a new one
+				// with a cold cache
+				clusters = append(clusters, t+1)
+				subMisses, subStart = 0, t+1
+				flush()
+			}
+		}
+	}
+	return clusters
+}
+
+// OptimizeVertexFetch reorders the vertices of m in the order they
+// are first referenced by m.Indices, m.LineIndices and m.PointIndices
+// and drops unreferenced vertices, so that vertex fetches access
+// memory mostly sequentially.
+func OptimizeVertexFetch(m *objloader.Model) {
+	const unused = math.MaxUint32
+
+	remap := make([]uint32, len(m.Vertices))
+	for i := range remap {
+		remap[i] = unused
+	}
+
+	var vertexCount uint32
+	for _, indices := range [][]uint32{m.Indices, m.LineIndices, m.PointIndices} {
+		for i, v := range indices {
+			if remap[v] == unused {
+				remap[v] = vertexCount
+				vertexCount++
+			}
+			indices[i] = remap[v]
+		}
+	}
+
+	n := len(m.Vertices)
+	m.Vertices = remapStream(m.Vertices, remap, n, vertexCount)
+	m.TextureCoords = remapStream(m.TextureCoords, remap, n, vertexCount)
+	m.Normals = remapStream(m.Normals, remap, n, vertexCount)
+	m.Colors = remapStream(m.Colors, remap, n, vertexCount)
+	m.Tangents = remapStream(m.Tangents, remap, n, vertexCount)
+	m.Joints = remapStream(m.Joints, remap, n, vertexCount)
+	m.Weights = remapStream(m.Weights, remap, n, vertexCount)
+}
+
+// remapStream moves the elements of a vertex stream of n vertices to
+// their new positions, streams of a different length are left alone.
+func remapStream[T any](stream []T, remap []uint32, n int, vertexCount uint32) []T {
+	if len(stream) != n {
+		return stream
+	}
+
+	out := make([]T, vertexCount)
+	for v, to := range remap {
+		if to != math.MaxUint32 {
+			out[to] = stream[v]
+		}
+	}
+	return out
+}
+
+// CompactIndices converts indices to 16 bits, it reports false if
+// any of them doesn't fit, i.e. the mesh has more than
+// MaxUint16Vertices vertices.
+func CompactIndices(indices []uint32) ([]uint16, bool) {
+	compact := make([]uint16, len(indices))
+	for i, v := range indices {
+		if v >= MaxUint16Vertices {
+			return nil, false
+		}
+		compact[i] = uint16(v)
+	}
+	return compact, true
+}
@@ -0,0 +1,71 @@
+package meshutil
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// triangles returns the triangles of m by their vertex positions,
+// rotated to start at their smallest vertex and sorted.
+func triangles(m *objloader.Model) [][3][3]float32 {
+	less := func(a, b [3]float32) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return a[i] < b[i]
+			}
+		}
+		return false
+	}
+
+	tris := make([][3][3]float32, 0, len(m.Indices)/3)
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		t := [3][3]float32{m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]}
+		for less(t[1], t[0]) || less(t[2], t[0]) {
+			t = [3][3]float32{t[1], t[2], t[0]}
+		}
+		tris = append(tris, t)
+	}
+	sort.Slice(tris, func(i, j int) bool {
+		for k := range tris[i] {
+			if tris[i][k] != tris[j][k] {
+				return less(tris[i][k], tris[j][k])
+			}
+		}
+		return false
+	})
+	return tris
+}
+
+func TestOptimize(t *testing.T) {
+	models, _, err := objloader.LoadObj(os.DirFS("../res"), "cube.obj", &objloader.LoadOptions{
+		GenerateNormals: objloader.NormalsSmooth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range models {
+		m := &models[i]
+		want := triangles(m)
+
+		before, after := Optimize(m)
+		t.Logf("%s: %d vertices, ACMR %.3f -> %.3f, ATVR %.3f -> %.3f",
+			m.Name, len(m.Vertices), before.ACMR, after.ACMR, before.ATVR, after.ATVR)
+
+		if after.ACMR > before.ACMR {
+			t.Errorf("%s: ACMR went from %v to %v", m.Name, before.ACMR, after.ACMR)
+		}
+		got := triangles(m)
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d triangles after optimizing, want %d", m.Name, len(got), len(want))
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("%s: triangle %v missing after optimizing", m.Name, want[j])
+			}
+		}
+	}
+}
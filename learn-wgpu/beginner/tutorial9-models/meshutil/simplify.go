@@ -0,0 +1,512 @@
+package meshutil
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// SimplifyOptions controls how far Simplify reduces a model.
+type SimplifyOptions struct {
+	// TargetTriangles is the triangle count simplification stops at,
+	// 0 simplifies as far as MaxError allows.
+	TargetTriangles int
+	// MaxError is the largest distance, in model units, the surface
+	// may move, 0 for no limit.
+	MaxError float32
+}
+
+// Simplify reduces the triangles of m by collapsing edges in order of
+// their quadric error, see Garland and Heckbert "Surface
+// Simplification Using Quadric Error Metrics". Vertices are only ever
+// collapsed onto other vertices, so the returned model shares the
+// vertex streams, lines and points of m and only has new Indices and
+// Submeshes.
+//
+// Vertices on borders only move along the border and vertices on
+// attribute seams, i.e. positions with two vertices, only move along
+// the seam together. Positions with more vertices are never moved.
+// The returned error is the largest distance the surface moved.
+func Simplify(m *objloader.Model, opts *SimplifyOptions) (lod objloader.Model, err float32) {
+	if opts == nil {
+		opts = &SimplifyOptions{}
+	}
+
+	s := newSimplifier(m)
+	maxCost := math.Inf(1)
+	if opts.MaxError > 0 {
+		maxCost = float64(opts.MaxError) * float64(opts.MaxError)
+	}
+
+	for len(s.indices)/3 > opts.TargetTriangles {
+		collapsed := s.pass(opts.TargetTriangles, maxCost)
+		if collapsed == 0 {
+			break
+		}
+	}
+
+	lod = *m
+	lod.Indices = s.indices
+	lod.Submeshes = nil
+	for t, sm := range s.submeshes {
+		if len(m.Submeshes) == 0 {
+			break
+		}
+		if n := len(lod.Submeshes); n == 0 || lod.Submeshes[n-1].MaterialName != m.Submeshes[sm].MaterialName {
+			lod.Submeshes = append(lod.Submeshes, objloader.Submesh{
+				MaterialName: m.Submeshes[sm].MaterialName,
+				IndexOffset:  uint32(t * 3),
+			})
+		}
+		lod.Submeshes[len(lod.Submeshes)-1].IndexCount += 3
+	}
+
+	return lod, float32(math.Sqrt(s.maxCost))
+}
+
+type vertexKind uint8
+
+const (
+	kindManifold vertexKind = iota
+	kindBorder
+	kindSeam
+	kindLocked
+)
+
+// quadric is a symmetric 4x4 matrix, stored as its upper triangle
+// a², ab, ac, ad, b², bc, bd, c², cd, d², followed by the sum of the
+// weights of the planes it was built from.
+type quadric [11]float64
+
+// planeQuadric is the quadric of the squared distance to the plane
+// through p with unit normal n, scaled by weight.
+func planeQuadric(n, p [3]float64, weight float64) quadric {
+	a, b, c := n[0], n[1], n[2]
+	d := -(a*p[0] + b*p[1] + c*p[2])
+	return quadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+		weight,
+	}
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// eval returns the weighted mean of the squared distances of p to the
+// planes of q.
+func (q *quadric) eval(p [3]float64) float64 {
+	x, y, z := p[0], p[1], p[2]
+	e := q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+	if e <= 0 || q[10] == 0 {
+		// rounding
+		return 0
+	}
+	return e / q[10]
+}
+
+func sub64(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func cross64(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func dot64(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func normalize64(a [3]float64) ([3]float64, float64) {
+	l := math.Sqrt(dot64(a, a))
+	if l == 0 {
+		return a, 0
+	}
+	return [3]float64{a[0] / l, a[1] / l, a[2] / l}, l
+}
+
+type simplifier struct {
+	// indices and the submesh of every triangle
+	indices   []uint32
+	submeshes []int
+
+	// positions of the welded positions, and the welded position of
+	// every vertex
+	positions [][3]float64
+	posOf     []uint32
+	quadrics  []quadric
+
+	// remap of the current pass, a collapsed vertex maps to the
+	// vertex it was collapsed onto
+	remap   []uint32
+	maxCost float64
+}
+
+func newSimplifier(m *objloader.Model) *simplifier {
+	s := &simplifier{
+		posOf: make([]uint32, len(m.Vertices)),
+		remap: make([]uint32, len(m.Vertices)),
+	}
+
+	welded := map[[3]float32]uint32{}
+	for v, p := range m.Vertices {
+		id, ok := welded[p]
+		if !ok {
+			id = uint32(len(s.positions))
+			welded[p] = id
+			s.positions = append(s.positions, [3]float64{float64(p[0]), float64(p[1]), float64(p[2])})
+		}
+		s.posOf[v] = id
+		s.remap[v] = uint32(v)
+	}
+
+	ranges := m.Submeshes
+	if len(ranges) == 0 {
+		ranges = []objloader.Submesh{{IndexCount: uint32(len(m.Indices))}}
+	}
+	for sm, r := range ranges {
+		indices := m.Indices[r.IndexOffset : r.IndexOffset+r.IndexCount]
+		for t := 0; t+2 < len(indices); t += 3 {
+			tri := indices[t : t+3]
+			if s.degenerate(tri[0], tri[1], tri[2]) {
+				continue
+			}
+			s.indices = append(s.indices, tri...)
+			s.submeshes = append(s.submeshes, sm)
+		}
+	}
+
+	// every position accumulates the planes of its triangles weighted
+	// by area, and planes perpendicular to its border and seam edges
+	// so that those keep their shape
+	const (
+		borderWeight = 10
+		seamWeight   = 1
+	)
+	edges := s.vertexEdges()
+	posEdges := s.positionEdges()
+	s.quadrics = make([]quadric, len(s.positions))
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		tri := s.indices[t : t+3]
+		p0, p1, p2 := s.positions[s.posOf[tri[0]]], s.positions[s.posOf[tri[1]]], s.positions[s.posOf[tri[2]]]
+		normal, doubleArea := normalize64(cross64(sub64(p1, p0), sub64(p2, p0)))
+		if doubleArea == 0 {
+			continue
+		}
+
+		q := planeQuadric(normal, p0, doubleArea/2)
+		for i := range tri {
+			s.quadrics[s.posOf[tri[i]]].add(q)
+		}
+
+		for i := range tri {
+			a, b := tri[i], tri[(i+1)%3]
+			if _, ok := edges[edgeKey(b, a)]; ok {
+				continue
+			}
+			weight := float64(seamWeight)
+			if _, ok := posEdges[edgeKey(s.posOf[b], s.posOf[a])]; !ok {
+				weight = borderWeight
+			}
+
+			pa, pb := s.positions[s.posOf[a]], s.positions[s.posOf[b]]
+			edge, length := normalize64(sub64(pb, pa))
+			if length == 0 {
+				continue
+			}
+			perpendicular, _ := normalize64(cross64(edge, normal))
+			eq := planeQuadric(perpendicular, pa, weight*length*length)
+			s.quadrics[s.posOf[a]].add(eq)
+			s.quadrics[s.posOf[b]].add(eq)
+		}
+	}
+
+	return s
+}
+
+func edgeKey(a, b uint32) uint64 {
+	return uint64(a)<<32 | uint64(b)
+}
+
+// vertexEdges returns the directed edges of the triangles.
+func (s *simplifier) vertexEdges() map[uint64]struct{} {
+	edges := make(map[uint64]struct{}, len(s.indices))
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		for i := 0; i < 3; i++ {
+			edges[edgeKey(s.indices[t+i], s.indices[t+(i+1)%3])] = struct{}{}
+		}
+	}
+	return edges
+}
+
+// positionEdges returns the directed edges of the triangles between
+// welded positions.
+func (s *simplifier) positionEdges() map[uint64]struct{} {
+	edges := make(map[uint64]struct{}, len(s.indices))
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		for i := 0; i < 3; i++ {
+			edges[edgeKey(s.posOf[s.indices[t+i]], s.posOf[s.indices[t+(i+1)%3]])] = struct{}{}
+		}
+	}
+	return edges
+}
+
+func (s *simplifier) degenerate(a, b, c uint32) bool {
+	pa, pb, pc := s.posOf[a], s.posOf[b], s.posOf[c]
+	return pa == pb || pb == pc || pc == pa
+}
+
+// classify returns the kind of every position and, for seam
+// positions, its two vertices.
+func (s *simplifier) classify(edges, posEdges map[uint64]struct{}) ([]vertexKind, [][2]uint32) {
+	type openEdges struct {
+		in, out int
+		// posOpen is set if any open edge is a border
+		posOpen bool
+	}
+	open := map[uint32]*openEdges{}
+	wedges := make([][2]uint32, len(s.positions))
+	wedgeCount := make([]int, len(s.positions))
+
+	for _, v := range s.indices {
+		p := s.posOf[v]
+		switch {
+		case wedgeCount[p] == 0:
+			wedges[p][0] = v
+			wedgeCount[p] = 1
+		case wedges[p][0] == v || wedgeCount[p] >= 2 && wedges[p][1] == v:
+		case wedgeCount[p] == 1:
+			wedges[p][1] = v
+			wedgeCount[p] = 2
+		default:
+			wedgeCount[p] = 3
+		}
+	}
+
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		for i := 0; i < 3; i++ {
+			a, b := s.indices[t+i], s.indices[t+(i+1)%3]
+			if _, ok := edges[edgeKey(b, a)]; ok {
+				continue
+			}
+			_, closed := posEdges[edgeKey(s.posOf[b], s.posOf[a])]
+			for _, v := range [2]uint32{a, b} {
+				if open[v] == nil {
+					open[v] = &openEdges{}
+				}
+				open[v].posOpen = open[v].posOpen || !closed
+			}
+			open[a].out++
+			open[b].in++
+		}
+	}
+
+	// once is true for a vertex with exactly one open edge in either
+	// direction, all of them borders if border is set
+	once := func(v uint32, border bool) bool {
+		o := open[v]
+		return o != nil && o.in == 1 && o.out == 1 && o.posOpen == border
+	}
+
+	kinds := make([]vertexKind, len(s.positions))
+	for p := range kinds {
+		switch wedgeCount[p] {
+		case 1:
+			v := wedges[p][0]
+			switch {
+			case open[v] == nil:
+				kinds[p] = kindManifold
+			case once(v, true):
+				kinds[p] = kindBorder
+			default:
+				kinds[p] = kindLocked
+			}
+		case 2:
+			kinds[p] = kindLocked
+			if once(wedges[p][0], false) && once(wedges[p][1], false) {
+				kinds[p] = kindSeam
+			}
+		default:
+			kinds[p] = kindLocked
+		}
+	}
+	return kinds, wedges
+}
+
+// pass does one round of collapses in order of their cost, each
+// position takes part in at most one of them, and returns the number
+// of collapses done.
+func (s *simplifier) pass(targetTriangles int, maxCost float64) int {
+	edges := s.vertexEdges()
+	posEdges := s.positionEdges()
+	kinds, wedges := s.classify(edges, posEdges)
+
+	// adjacency[offsets[p]:offsets[p+1]] are the triangles using
+	// position p
+	offsets := make([]uint32, len(s.positions)+1)
+	for _, v := range s.indices {
+		offsets[s.posOf[v]+1]++
+	}
+	for p := range s.positions {
+		offsets[p+1] += offsets[p]
+	}
+	adjacency := make([]uint32, len(s.indices))
+	fill := append([]uint32(nil), offsets[:len(s.positions)]...)
+	for i, v := range s.indices {
+		p := s.posOf[v]
+		adjacency[fill[p]] = uint32(i / 3)
+		fill[p]++
+	}
+
+	isOpen := func(a, b uint32) bool {
+		_, ab := edges[edgeKey(b, a)]
+		_, ba := edges[edgeKey(a, b)]
+		return !ab || !ba
+	}
+
+	type collapse struct {
+		from, to uint32
+		cost     float64
+	}
+	var candidates []collapse
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		for i := 0; i < 3; i++ {
+			for _, e := range [2][2]uint32{
+				{s.indices[t+i], s.indices[t+(i+1)%3]},
+				{s.indices[t+(i+1)%3], s.indices[t+i]},
+			} {
+				from, to := e[0], e[1]
+				switch kinds[s.posOf[from]] {
+				case kindLocked:
+					continue
+				case kindBorder, kindSeam:
+					if !isOpen(from, to) {
+						continue
+					}
+				}
+				cost := s.quadrics[s.posOf[from]].eval(s.positions[s.posOf[to]])
+				candidates = append(candidates, collapse{from, to, cost})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].cost < candidates[j].cost })
+
+	locked := make([]bool, len(s.positions))
+	triangles := len(s.indices) / 3
+	collapsed := 0
+
+	for _, c := range candidates {
+		if triangles <= targetTriangles || c.cost > maxCost {
+			break
+		}
+
+		pFrom, pTo := s.posOf[c.from], s.posOf[c.to]
+		if locked[pFrom] || locked[pTo] {
+			continue
+		}
+
+		// a seam collapses both of its vertices, onto the vertex at
+		// pTo the other one shares a seam edge with
+		other, otherTo := uint32(0), uint32(0)
+		if kinds[pFrom] == kindSeam {
+			other = wedges[pFrom][0]
+			if other == c.from {
+				other = wedges[pFrom][1]
+			}
+
+			found := false
+			for _, t := range adjacency[offsets[pFrom]:offsets[pFrom+1]] {
+				tri := s.indices[t*3 : t*3+3]
+				for i := range tri {
+					a, b := tri[i], tri[(i+1)%3]
+					if a == other && s.posOf[b] == pTo && isOpen(a, b) {
+						otherTo, found = b, true
+					} else if b == other && s.posOf[a] == pTo && isOpen(a, b) {
+						otherTo, found = a, true
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+
+		removed, flips := s.checkCollapse(adjacency[offsets[pFrom]:offsets[pFrom+1]], pFrom, pTo)
+		if flips {
+			continue
+		}
+
+		s.remap[c.from] = c.to
+		if kinds[pFrom] == kindSeam {
+			s.remap[other] = otherTo
+		}
+		s.quadrics[pTo].add(s.quadrics[pFrom])
+		locked[pFrom], locked[pTo] = true, true
+		triangles -= removed
+		collapsed++
+		if c.cost > s.maxCost {
+			s.maxCost = c.cost
+		}
+	}
+
+	// apply the collapses and drop the triangles they made degenerate
+	indices, submeshes := s.indices[:0], s.submeshes[:0]
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := s.remap[s.indices[t]], s.remap[s.indices[t+1]], s.remap[s.indices[t+2]]
+		if s.degenerate(a, b, c) {
+			continue
+		}
+		indices = append(indices, a, b, c)
+		submeshes = append(submeshes, s.submeshes[t/3])
+	}
+	s.indices, s.submeshes = indices, submeshes
+	for v := range s.remap {
+		s.remap[v] = uint32(v)
+	}
+
+	return collapsed
+}
+
+// checkCollapse returns how many of the triangles around position
+// from are removed by moving it onto position to, and whether any of
+// the remaining ones would flip or turn by more than ~75 degrees.
+func (s *simplifier) checkCollapse(triangles []uint32, from, to uint32) (removed int, flips bool) {
+	target := s.positions[to]
+
+	for _, t := range triangles {
+		var p [3]uint32
+		for i, v := range s.indices[t*3 : t*3+3] {
+			p[i] = s.posOf[s.remap[v]]
+		}
+		if p[0] == p[1] || p[1] == p[2] || p[2] == p[0] {
+			// already removed by an earlier collapse
+			continue
+		}
+		if p[0] == to || p[1] == to || p[2] == to {
+			removed++
+			continue
+		}
+
+		// rotate from to the front
+		for p[0] != from {
+			p = [3]uint32{p[1], p[2], p[0]}
+		}
+		b, c := s.positions[p[1]], s.positions[p[2]]
+
+		before := cross64(sub64(b, s.positions[from]), sub64(c, s.positions[from]))
+		after := cross64(sub64(b, target), sub64(c, target))
+		if dot64(before, after) <= 0.25*math.Sqrt(dot64(before, before)*dot64(after, after)) {
+			return removed, true
+		}
+	}
+	return removed, false
+}
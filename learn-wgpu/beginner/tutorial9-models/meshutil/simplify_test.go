@@ -0,0 +1,187 @@
+package meshutil
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// simplifyGrid returns a size*size grid of quads in the xy plane,
+// displaced along z by height. The left and right halves use the
+// materials "left" and "right" and are split by a texture seam at
+// x = size/2, where the vertices of the right half have u offset by 1.
+func simplifyGrid(size int, height func(x, y int) float32) objloader.Model {
+	m := objloader.Model{Name: "grid"}
+	half := size / 2
+
+	// vertex returns the vertex at x, y of the left or right half
+	vertex := func(x, y int, right bool) uint32 {
+		u := float32(x) / float32(size)
+		if right {
+			u++
+		}
+		m.Vertices = append(m.Vertices, [3]float32{float32(x), float32(y), height(x, y)})
+		m.TextureCoords = append(m.TextureCoords, [3]float32{u, float32(y) / float32(size), 0})
+		return uint32(len(m.Vertices) - 1)
+	}
+	for _, side := range []struct {
+		name     string
+		from, to int
+		right    bool
+	}{
+		{"left", 0, half, false},
+		{"right", half, size, true},
+	} {
+		ids := map[[2]int]uint32{}
+		for y := 0; y <= size; y++ {
+			for x := side.from; x <= side.to; x++ {
+				ids[[2]int{x, y}] = vertex(x, y, side.right)
+			}
+		}
+		offset := len(m.Indices)
+		for y := 0; y < size; y++ {
+			for x := side.from; x < side.to; x++ {
+				a, b := ids[[2]int{x, y}], ids[[2]int{x + 1, y}]
+				c, d := ids[[2]int{x + 1, y + 1}], ids[[2]int{x, y + 1}]
+				m.Indices = append(m.Indices, a, b, c, a, c, d)
+			}
+		}
+		m.Submeshes = append(m.Submeshes, objloader.Submesh{
+			MaterialName: side.name,
+			IndexOffset:  uint32(offset),
+			IndexCount:   uint32(len(m.Indices) - offset),
+		})
+	}
+	return m
+}
+
+// checkSubmeshes checks that the submeshes of lod cover its indices in
+// order and keep the materials of m.
+func checkSubmeshes(t *testing.T, m, lod *objloader.Model) {
+	t.Helper()
+	offset := uint32(0)
+	for i, sm := range lod.Submeshes {
+		if sm.IndexOffset != offset {
+			t.Errorf("submesh %d starts at %d, want %d", i, sm.IndexOffset, offset)
+		}
+		if i >= len(m.Submeshes) || sm.MaterialName != m.Submeshes[i].MaterialName {
+			t.Errorf("submesh %d has material %q", i, sm.MaterialName)
+		}
+		offset += sm.IndexCount
+	}
+	if offset != uint32(len(lod.Indices)) {
+		t.Errorf("submeshes cover %d of %d indices", offset, len(lod.Indices))
+	}
+}
+
+// checkGrid checks that the simplified grid of the given size keeps
+// the outline and seam of the grid: the triangles of each half stay on
+// their side of the seam, with the texture coordinates of that side,
+// and together still cover the half.
+func checkGrid(t *testing.T, lod *objloader.Model, size int) {
+	t.Helper()
+	half := float32(size / 2)
+	for i, sm := range lod.Submeshes {
+		right := sm.MaterialName == "right"
+		indices := lod.Indices[sm.IndexOffset : sm.IndexOffset+sm.IndexCount]
+		for _, v := range indices {
+			p, uv := lod.Vertices[v], lod.TextureCoords[v]
+			if right && (p[0] < half || uv[0] < 1) || !right && (p[0] > half || uv[0] > 0.5) {
+				t.Errorf("submesh %s: vertex %v with texture coordinate %v on the wrong side of the seam", sm.MaterialName, p, uv)
+			}
+		}
+		// the area of the triangles projected onto the grid
+		var area float32
+		for j := 0; j+2 < len(indices); j += 3 {
+			p0, p1, p2 := lod.Vertices[indices[j]], lod.Vertices[indices[j+1]], lod.Vertices[indices[j+2]]
+			area += ((p1[0]-p0[0])*(p2[1]-p0[1]) - (p1[1]-p0[1])*(p2[0]-p0[0])) / 2
+		}
+		if want := half * float32(size); area != want {
+			t.Errorf("submesh %d: area %v, want %v", i, area, want)
+		}
+	}
+}
+
+func TestSimplifyGrid(t *testing.T) {
+	const size = 8
+	m := simplifyGrid(size, func(x, y int) float32 { return 0 })
+
+	// every vertex but the corners and the ends of the seam can be
+	// removed without moving the surface
+	lod, err := Simplify(&m, &SimplifyOptions{MaxError: 0.001})
+	if err != 0 {
+		t.Errorf("error %v, want 0", err)
+	}
+	if n := len(lod.Indices) / 3; n != 4 {
+		t.Errorf("%d triangles, want 4", n)
+	}
+	checkSubmeshes(t, &m, &lod)
+	checkGrid(t, &lod, size)
+}
+
+func TestSimplifyTargetTriangles(t *testing.T) {
+	const size = 8
+	m := simplifyGrid(size, func(x, y int) float32 { return float32(x*(size-x)*y*(size-y)) / 64 })
+
+	var prevErr float32
+	for _, target := range []int{128, 96, 64, 32, 16, 8} {
+		lod, err := Simplify(&m, &SimplifyOptions{TargetTriangles: target})
+		if n := len(lod.Indices) / 3; n > target || n < target-2 {
+			t.Errorf("target %d: %d triangles", target, n)
+		}
+		if err < prevErr {
+			t.Errorf("target %d: error %v, less than %v with more triangles", target, err, prevErr)
+		}
+		prevErr = err
+		checkSubmeshes(t, &m, &lod)
+		checkGrid(t, &lod, size)
+	}
+}
+
+func TestSimplifyMaxError(t *testing.T) {
+	const size = 8
+	m := simplifyGrid(size, func(x, y int) float32 { return float32(x*(size-x)*y*(size-y)) / 64 })
+
+	prevTriangles := len(m.Indices) / 3
+	for _, maxError := range []float32{0.01, 0.05, 0.1, 0.5, 1} {
+		lod, err := Simplify(&m, &SimplifyOptions{MaxError: maxError})
+		if err > maxError {
+			t.Errorf("max error %v: error %v", maxError, err)
+		}
+		n := len(lod.Indices) / 3
+		if n > prevTriangles {
+			t.Errorf("max error %v: %d triangles, more than %d with a smaller error", maxError, n, prevTriangles)
+		}
+		prevTriangles = n
+		checkSubmeshes(t, &m, &lod)
+		checkGrid(t, &lod, size)
+	}
+	if prevTriangles == len(m.Indices)/3 {
+		t.Error("no triangles were removed")
+	}
+}
+
+func TestSimplifyCube(t *testing.T) {
+	models, _, err := objloader.LoadObj(os.DirFS("../res"), "cube.obj", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range models {
+		m := &models[i]
+		triangles := len(m.Indices) / 3
+		for _, target := range []int{triangles / 2, triangles / 4, triangles / 8} {
+			lod, err := Simplify(m, &SimplifyOptions{TargetTriangles: target})
+			n := len(lod.Indices) / 3
+			t.Logf("%s: %d -> %d triangles, target %d, error %v", m.Name, triangles, n, target, err)
+			if n > target {
+				t.Errorf("%s: target %d: %d triangles", m.Name, target, n)
+			}
+			if &lod.Vertices[0] != &m.Vertices[0] {
+				t.Errorf("%s: vertices were copied", m.Name)
+			}
+			checkSubmeshes(t, m, &lod)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package meshutil
+
+import (
+	"errors"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// Skin returns a copy of m with its vertices, normals and tangents
+// moved by the joint matrices they are weighted to, i.e. linear blend
+// skinning on the CPU. Like the vertex shader it uses the weighted sum
+// of the joint matrices as is, vertices with all weights zero are left
+// in place.
+func Skin(m *objloader.Model, joints []glm.Mat4[float32]) (objloader.Model, error) {
+	if !m.HasSkin() {
+		return objloader.Model{}, errors.New("skinning requires joints and weights")
+	}
+
+	out := *m
+	out.Vertices = make([][3]float32, len(m.Vertices))
+	hasNormals, hasTangents := m.HasNormals(), len(m.Tangents) == len(m.Vertices)
+	if hasNormals {
+		out.Normals = make([][3]float32, len(m.Normals))
+	}
+	if hasTangents {
+		out.Tangents = make([][4]float32, len(m.Tangents))
+	}
+	for v := range m.Vertices {
+		skin, err := skinMatrix(m.Joints[v], m.Weights[v], joints)
+		if err != nil {
+			return objloader.Model{}, err
+		}
+
+		out.Vertices[v] = skin.MulVec4(glm.Vec3[float32](m.Vertices[v]).Extend(1)).Truncate()
+		if hasNormals {
+			n := skin.Mat3().MulVec3(m.Normals[v])
+			if n.Magnitude() > 0 {
+				n = n.Normalize()
+			}
+			out.Normals[v] = n
+		}
+		if hasTangents {
+			t := m.Tangents[v]
+			tangent := skin.Mat3().MulVec3(glm.Vec3[float32]{t[0], t[1], t[2]})
+			if tangent.Magnitude() > 0 {
+				tangent = tangent.Normalize()
+			}
+			out.Tangents[v] = [4]float32{tangent[0], tangent[1], tangent[2], t[3]}
+		}
+	}
+	return out, nil
+}
+
+func skinMatrix(indices [4]uint16, weights [4]float32, joints []glm.Mat4[float32]) (glm.Mat4[float32], error) {
+	if weights == ([4]float32{}) {
+		return glm.Mat4Identity[float32](), nil
+	}
+
+	var skin glm.Mat4[float32]
+	for i, j := range indices {
+		if weights[i] == 0 {
+			continue
+		}
+		if int(j) >= len(joints) {
+			return skin, errors.New("joint index out of range")
+		}
+		for e := range skin {
+			skin[e] += joints[j][e] * weights[i]
+		}
+	}
+	return skin, nil
+}
@@ -0,0 +1,114 @@
+package meshutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/gltfloader"
+)
+
+// skinnedDocument returns a glTF document of a quad skinned to a chain
+// of two joints bent by 90 degrees, with the inverse bind matrices of
+// the straight chain. The last vertex has no weights.
+func skinnedDocument() string {
+	var b bytes.Buffer
+	write := func(v any) { binary.Write(&b, binary.LittleEndian, v) }
+
+	write([][3]float32{{-1, 0, 0}, {1, 0, 0}, {-1, 2, 0}, {1, 2, 0}})
+	write([][4]float32{{1, 0, 0, 0}, {0.5, 0.5, 0, 0}, {0.25, 0.75, 0, 0}, {}})
+	write([][4]uint16{{0, 0, 0, 0}, {0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 0, 0}})
+	write([]uint16{0, 1, 2, 1, 3, 2})
+	write(glm.Mat4FromTranslation(glm.Vec3[float32]{0, -1, 0}))
+	write(glm.Mat4FromTranslation(glm.Vec3[float32]{0, -2, 0}))
+
+	return fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"byteLength": %d, "uri": "data:application/octet-stream;base64,%s"}],
+		"bufferViews": [
+			{"buffer": 0, "byteLength": 144},
+			{"buffer": 0, "byteOffset": 144, "byteLength": 12},
+			{"buffer": 0, "byteOffset": 156, "byteLength": 128}
+		],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3", "min": [-1, 0, 0], "max": [1, 2, 0]},
+			{"bufferView": 0, "byteOffset": 48, "componentType": 5126, "count": 4, "type": "VEC4"},
+			{"bufferView": 0, "byteOffset": 112, "componentType": 5123, "count": 4, "type": "VEC4"},
+			{"bufferView": 1, "componentType": 5123, "count": 6, "type": "SCALAR"},
+			{"bufferView": 2, "componentType": 5126, "count": 2, "type": "MAT4"}
+		],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "WEIGHTS_0": 1, "JOINTS_0": 2}, "indices": 3}]}],
+		"skins": [{"joints": [1, 2], "inverseBindMatrices": 4}],
+		"nodes": [
+			{"mesh": 0, "skin": 0},
+			{"translation": [0, 1, 0], "rotation": [0, 0, 0.38268343, 0.9238795], "children": [2]},
+			{"translation": [0, 1, 0], "rotation": [0, 0, 0.38268343, 0.9238795]}
+		],
+		"scenes": [{"nodes": [0, 1]}]
+	}`, b.Len(), base64.StdEncoding.EncodeToString(b.Bytes()))
+}
+
+// shaderSkin moves position like vs_main in shader.wgsl: the weighted
+// sum of the joint matrices, or the identity for a vertex without
+// weights, times the position.
+func shaderSkin(position [3]float32, indices [4]uint16, weights [4]float32, joints []glm.Mat4[float32]) [3]float32 {
+	skin := glm.Mat4Identity[float32]()
+	if weights != ([4]float32{}) {
+		skin = glm.Mat4[float32]{}
+		for i, j := range indices {
+			for e := range skin {
+				skin[e] += joints[j][e] * weights[i]
+			}
+		}
+	}
+
+	// the matrices are column major
+	p := [4]float32{position[0], position[1], position[2], 1}
+	var out [3]float32
+	for row := range out {
+		for col := range p {
+			out[row] += skin[col*4+row] * p[col]
+		}
+	}
+	return out
+}
+
+func TestSkin(t *testing.T) {
+	doc, err := gltfloader.LoadReader(strings.NewReader(skinnedDocument()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := doc.Flatten(-1)[0]
+	joints := doc.JointMatrices(nil)
+
+	skinned, err := Skin(&m, joints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moved := false
+	for v, p := range m.Vertices {
+		want := shaderSkin(p, m.Joints[v], m.Weights[v], joints)
+		got := skinned.Vertices[v]
+		for i := range got {
+			if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+				t.Errorf("vertex %d: meshutil.Skin %v, shader %v", v, got, want)
+				break
+			}
+		}
+		if got != p {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Error("the pose moved no vertex")
+	}
+	if skinned.Vertices[3] != m.Vertices[3] {
+		t.Errorf("vertex without weights moved from %v to %v", m.Vertices[3], skinned.Vertices[3])
+	}
+}
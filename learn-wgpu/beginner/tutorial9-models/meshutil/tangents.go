@@ -0,0 +1,164 @@
+package meshutil
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// GenerateTangents computes a tangent for every vertex of m and
+// stores it in m.Tangents, with the bitangent sign in w so that
+// bitangent = cross(normal, tangent.xyz) * tangent.w.
+//
+// Like MikkTSpace, the tangent of a triangle is projected onto the
+// tangent plane of each of its vertices and accumulated weighted by
+// corner angle, and triangles with mirrored texture coordinates are
+// never averaged together. A vertex shared by triangles of both
+// orientations is split in two, which appends to the vertex streams
+// of m and rewrites m.Indices accordingly.
+func GenerateTangents(m *objloader.Model) error {
+	if !m.HasNormals() {
+		return errors.New("tangent generation requires normals")
+	}
+	if !m.HasTextureCoords() {
+		return errors.New("tangent generation requires texture coordinates")
+	}
+
+	type group struct {
+		tangent glm.Vec3[float32]
+		used    bool
+		// the vertex the group is stored in, -1 until assigned
+		vertex int
+	}
+
+	// groups[vertex][0] collects triangles with positive orientation
+	// in texture space and groups[vertex][1] the mirrored ones
+	groups := make([][2]group, len(m.Vertices))
+	for i := range groups {
+		groups[i] = [2]group{{vertex: -1}, {vertex: -1}}
+	}
+
+	orientations := make([]int, len(m.Indices)/3)
+
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		idx := [3]uint32{m.Indices[t], m.Indices[t+1], m.Indices[t+2]}
+
+		var p [3]glm.Vec3[float32]
+		var uv [3]glm.Vec2[float32]
+		for i, vi := range idx {
+			p[i] = m.Vertices[vi]
+			uv[i] = glm.Vec3[float32](m.TextureCoords[vi]).Truncate()
+		}
+
+		d1, d2 := p[1].Sub(p[0]), p[2].Sub(p[0])
+		st1, st2 := uv[1].Sub(uv[0]), uv[2].Sub(uv[0])
+
+		signedArea := st1.Cross(st2)
+		orientation := 0
+		if signedArea < 0 {
+			orientation = 1
+		}
+		orientations[t/3] = orientation
+
+		for _, vi := range idx {
+			groups[vi][orientation].used = true
+		}
+
+		if signedArea == 0 {
+			// degenerate in texture space, contributes nothing
+			continue
+		}
+
+		os := d1.MulScalar(st2[1]).Sub(d2.MulScalar(st1[1])).DivScalar(signedArea)
+		if os.Magnitude() == 0 {
+			continue
+		}
+		os = os.Normalize()
+
+		for i, vi := range idx {
+			n := glm.Vec3[float32](m.Normals[vi])
+			e1 := p[(i+1)%3].Sub(p[i])
+			e2 := p[(i+2)%3].Sub(p[i])
+
+			tangent := projectOnPlane(os, n)
+			weight := angleBetween(projectOnPlane(e1, n), projectOnPlane(e2, n))
+
+			g := &groups[vi][orientation]
+			g.tangent = g.tangent.Add(tangent.MulScalar(weight))
+		}
+	}
+
+	tangents := make([][4]float32, len(m.Vertices))
+
+	for vi := range groups {
+		for orientation := range groups[vi] {
+			g := &groups[vi][orientation]
+			if !g.used {
+				continue
+			}
+
+			vertex := vi
+			if orientation == 1 && groups[vi][0].vertex != -1 {
+				vertex = len(m.Vertices)
+				m.Vertices = append(m.Vertices, m.Vertices[vi])
+				m.TextureCoords = append(m.TextureCoords, m.TextureCoords[vi])
+				m.Normals = append(m.Normals, m.Normals[vi])
+				if len(m.Colors) != 0 {
+					m.Colors = append(m.Colors, m.Colors[vi])
+				}
+				if len(m.Joints) != 0 {
+					m.Joints = append(m.Joints, m.Joints[vi])
+					m.Weights = append(m.Weights, m.Weights[vi])
+				}
+				tangents = append(tangents, [4]float32{})
+			}
+			g.vertex = vertex
+
+			tangent := g.tangent
+			if tangent.Magnitude() == 0 {
+				tangent = anyPerpendicular(glm.Vec3[float32](m.Normals[vi]))
+			}
+			tangent = tangent.Normalize()
+
+			sign := float32(1)
+			if orientation == 1 {
+				sign = -1
+			}
+			tangents[vertex] = [4]float32{tangent[0], tangent[1], tangent[2], sign}
+		}
+	}
+
+	for i, vi := range m.Indices[:len(orientations)*3] {
+		if g := groups[vi][orientations[i/3]]; g.vertex != -1 {
+			m.Indices[i] = uint32(g.vertex)
+		}
+	}
+
+	m.Tangents = tangents
+	return nil
+}
+
+func projectOnPlane(v, n glm.Vec3[float32]) glm.Vec3[float32] {
+	return v.Sub(n.MulScalar(n.Dot(v)))
+}
+
+func angleBetween(a, b glm.Vec3[float32]) float32 {
+	l := a.Magnitude() * b.Magnitude()
+	if l == 0 {
+		return 0
+	}
+
+	cos := float64(a.Dot(b) / l)
+	return float32(math.Acos(math.Max(-1, math.Min(1, cos))))
+}
+
+// anyPerpendicular returns a unit vector perpendicular to n.
+func anyPerpendicular(n glm.Vec3[float32]) glm.Vec3[float32] {
+	axis := glm.Vec3[float32]{1, 0, 0}
+	if math.Abs(float64(n[0])) > 0.9 {
+		axis = glm.Vec3[float32]{0, 1, 0}
+	}
+	return projectOnPlane(axis, n).Normalize()
+}
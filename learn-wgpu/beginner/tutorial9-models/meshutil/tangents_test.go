@@ -0,0 +1,100 @@
+package meshutil
+
+import (
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+func approxTangent(a, b [4]float32) bool {
+	for i := range a {
+		if d := a[i] - b[i]; d > 1e-5 || d < -1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGenerateTangents(t *testing.T) {
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	for _, tt := range []struct {
+		name          string
+		textureCoords [][3]float32
+		want          [4]float32
+	}{
+		{"u along x", [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}, [4]float32{1, 0, 0, 1}},
+		{"u along -x", [][3]float32{{1, 1, 0}, {0, 1, 0}, {0, 0, 0}, {1, 0, 0}}, [4]float32{-1, 0, 0, 1}},
+		// u along y with v along x is mirrored
+		{"u along y", [][3]float32{{0, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}}, [4]float32{0, 1, 0, -1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := objloader.Model{
+				Vertices:      positions,
+				TextureCoords: tt.textureCoords,
+				Normals:       [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+				Indices:       []uint32{0, 1, 2, 0, 2, 3},
+			}
+			if err := GenerateTangents(&m); err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Vertices) != 4 {
+				t.Errorf("%d vertices, want 4", len(m.Vertices))
+			}
+			for i, tangent := range m.Tangents {
+				if !approxTangent(tangent, tt.want) {
+					t.Errorf("vertex %d: tangent %v, want %v", i, tangent, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateTangentsMirrored(t *testing.T) {
+	// two quads sharing the edge at x = 0, with the texture mirrored
+	// across it so the left quad has u along -x
+	m := objloader.Model{
+		Vertices:      [][3]float32{{-1, 0, 0}, {0, 0, 0}, {1, 0, 0}, {-1, 1, 0}, {0, 1, 0}, {1, 1, 0}},
+		TextureCoords: [][3]float32{{1, 0, 0}, {0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {1, 1, 0}},
+		Normals:       [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		Colors:        [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 1, 0}, {0, 0, 0}},
+		Indices: []uint32{
+			0, 1, 4, 0, 4, 3, // left
+			1, 2, 5, 1, 5, 4, // right
+		},
+	}
+	if err := GenerateTangents(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	// the two vertices on the shared edge are split
+	if len(m.Vertices) != 8 || len(m.TextureCoords) != 8 || len(m.Normals) != 8 || len(m.Colors) != 8 || len(m.Tangents) != 8 {
+		t.Fatalf("%d vertices, %d texture coordinates, %d normals, %d colours and %d tangents, want 8",
+			len(m.Vertices), len(m.TextureCoords), len(m.Normals), len(m.Colors), len(m.Tangents))
+	}
+	for i, v := range []uint32{1, 4} {
+		split := uint32(6 + i)
+		if m.Vertices[split] != m.Vertices[v] || m.TextureCoords[split] != m.TextureCoords[v] || m.Colors[split] != m.Colors[v] {
+			t.Errorf("vertex %d is not a copy of vertex %d", split, v)
+		}
+	}
+
+	// the mirrored triangles use the new vertices, the others keep
+	// the original ones
+	want := []uint32{0, 6, 7, 0, 7, 3, 1, 2, 5, 1, 5, 4}
+	for i := range want {
+		if m.Indices[i] != want[i] {
+			t.Fatalf("indices %v, want %v", m.Indices, want)
+		}
+	}
+
+	for i, v := range m.Indices {
+		tangent := m.Tangents[v]
+		want := [4]float32{1, 0, 0, 1}
+		if i < 6 {
+			want = [4]float32{-1, 0, 0, -1}
+		}
+		if !approxTangent(tangent, want) {
+			t.Errorf("index %d: vertex %d: tangent %v, want %v", i, v, tangent, want)
+		}
+	}
+}
@@ -3,13 +3,22 @@ package main
 import (
 	"unsafe"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/gltfloader"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
 type ModelVertex struct {
-	Position  [3]float32
-	TexCoords [2]float32
-	Normal    [3]float32
+	Position  glm.Vec3[float32]
+	TexCoords glm.Vec2[float32]
+	Normal    glm.Vec3[float32]
+	Tangent   glm.Vec4[float32]
+	Color     glm.Vec3[float32]
+	// Joints index the joint matrices, vertices whose Weights are all
+	// zero aren't skinned.
+	Joints  [4]uint16
+	Weights glm.Vec4[float32]
 }
 
 var ModelVertexLayout = wgpu.VertexBufferLayout{
@@ -31,6 +40,26 @@ var ModelVertexLayout = wgpu.VertexBufferLayout{
 			ShaderLocation: 2,
 			Format:         wgpu.VertexFormat_Float32x3,
 		},
+		{
+			Offset:         0 + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x2.Size() + wgpu.VertexFormat_Float32x3.Size(),
+			ShaderLocation: 3,
+			Format:         wgpu.VertexFormat_Float32x4,
+		},
+		{
+			Offset:         0 + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x2.Size() + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x4.Size(),
+			ShaderLocation: 4,
+			Format:         wgpu.VertexFormat_Float32x3,
+		},
+		{
+			Offset:         0 + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x2.Size() + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x4.Size() + wgpu.VertexFormat_Float32x3.Size(),
+			ShaderLocation: 12,
+			Format:         wgpu.VertexFormat_Uint16x4,
+		},
+		{
+			Offset:         0 + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x2.Size() + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Float32x4.Size() + wgpu.VertexFormat_Float32x3.Size() + wgpu.VertexFormat_Uint16x4.Size(),
+			ShaderLocation: 13,
+			Format:         wgpu.VertexFormat_Float32x4,
+		},
 	},
 }
 
@@ -40,23 +69,66 @@ type Material struct {
 	BindGroup      *wgpu.BindGroup
 }
 
+// Lod is a coarser level of detail of a Mesh, drawn with the vertex
+// buffer of the mesh.
+type Lod struct {
+	IndexBuffer *wgpu.Buffer
+	NumElements uint32
+	Submeshes   []Submesh
+	// Error is the largest distance the surface moved, in model units
+	Error float32
+}
+
+type Submesh struct {
+	FirstIndex  uint32
+	NumElements uint32
+	MaterialIdx int
+}
+
+// Mesh holds the triangles, lines and points of a model in separate
+// index buffers, each of which is nil if the model has none of them.
 type Mesh struct {
 	Name         string
 	VertexBuffer *wgpu.Buffer
 	IndexBuffer  *wgpu.Buffer
+	IndexFormat  wgpu.IndexFormat
 	NumElements  uint32
-	MaterialIdx  int
+	Submeshes    []Submesh
+	// Lods[i] is level of detail i+1, the mesh itself being level 0
+	Lods             []Lod
+	LineIndexBuffer  *wgpu.Buffer
+	NumLineElements  uint32
+	PointIndexBuffer *wgpu.Buffer
+	NumPointElements uint32
+	// Morphed meshes have morph targets, their vertex buffer is
+	// rewritten when the weights are animated
+	Morphed bool
 }
 
 type Model struct {
 	Meshes    []Mesh
 	Materials []Material
+	Bounds    glm.AABB[float32]
+	ObjModels []objloader.Model
+	// Document is the glTF asset the model was loaded from, nil for
+	// other formats.
+	Document *gltfloader.Document
+	// LodErrors[i] is the largest error of level of detail i+1 among
+	// the meshes
+	LodErrors []float32
 }
 
 func (m *Model) Destroy() {
 	for _, mesh := range m.Meshes {
 		mesh.VertexBuffer.Release()
-		mesh.IndexBuffer.Release()
+		for _, buffer := range []*wgpu.Buffer{mesh.IndexBuffer, mesh.LineIndexBuffer, mesh.PointIndexBuffer} {
+			if buffer != nil {
+				buffer.Release()
+			}
+		}
+		for _, lod := range mesh.Lods {
+			lod.IndexBuffer.Release()
+		}
 	}
 	m.Meshes = nil
 
@@ -67,14 +139,73 @@ func (m *Model) Destroy() {
 	m.Materials = nil
 }
 
-func drawModelInstanced(renderPass *wgpu.RenderPassEncoder, model *Model, cameraBindGroup *wgpu.BindGroup, instanceCount uint32) {
+// InstanceRange is a range of the instance buffer drawn at the same
+// level of detail.
+type InstanceRange struct {
+	First uint32
+	Count uint32
+}
+
+// drawModelInstanced draws the triangles of the model, the instances
+// in lodInstances[level] at that level of detail.
+func drawModelInstanced(renderPass *wgpu.RenderPassEncoder, model *Model, cameraBindGroup *wgpu.BindGroup, lodInstances []InstanceRange) {
+	for _, mesh := range model.Meshes {
+		if mesh.IndexBuffer == nil {
+			continue
+		}
+
+		renderPass.SetVertexBuffer(0, mesh.VertexBuffer, 0, wgpu.WholeSize)
+		renderPass.SetBindGroup(1, cameraBindGroup, nil)
+
+		for level, instances := range lodInstances {
+			if instances.Count == 0 {
+				continue
+			}
+
+			indexBuffer, submeshes := mesh.IndexBuffer, mesh.Submeshes
+			if level > 0 && len(mesh.Lods) != 0 {
+				// meshes with fewer levels use their coarsest one
+				lod := mesh.Lods[len(mesh.Lods)-1]
+				if level <= len(mesh.Lods) {
+					lod = mesh.Lods[level-1]
+				}
+				indexBuffer, submeshes = lod.IndexBuffer, lod.Submeshes
+			}
+			renderPass.SetIndexBuffer(indexBuffer, mesh.IndexFormat, 0, wgpu.WholeSize)
+
+			for _, submesh := range submeshes {
+				material := model.Materials[submesh.MaterialIdx]
+
+				renderPass.SetBindGroup(0, material.BindGroup, nil)
+				renderPass.DrawIndexed(submesh.NumElements, instances.Count, submesh.FirstIndex, 0, instances.First)
+			}
+		}
+	}
+}
+
+// drawModelPrimitivesInstanced draws the lines or points of the model,
+// depending on topology, which must be PrimitiveTopology_LineList or
+// PrimitiveTopology_PointList. They are coloured by their vertex
+// colours only, but the pipeline layout still needs the first
+// material to be bound.
+func drawModelPrimitivesInstanced(renderPass *wgpu.RenderPassEncoder, model *Model, topology wgpu.PrimitiveTopology, cameraBindGroup *wgpu.BindGroup, instanceCount uint32) {
+	if len(model.Materials) == 0 {
+		return
+	}
+
 	for _, mesh := range model.Meshes {
-		material := model.Materials[mesh.MaterialIdx]
+		indexBuffer, numElements := mesh.LineIndexBuffer, mesh.NumLineElements
+		if topology == wgpu.PrimitiveTopology_PointList {
+			indexBuffer, numElements = mesh.PointIndexBuffer, mesh.NumPointElements
+		}
+		if indexBuffer == nil {
+			continue
+		}
 
 		renderPass.SetVertexBuffer(0, mesh.VertexBuffer, 0, wgpu.WholeSize)
-		renderPass.SetIndexBuffer(mesh.IndexBuffer, wgpu.IndexFormat_Uint32, 0, wgpu.WholeSize)
-		renderPass.SetBindGroup(0, material.BindGroup, nil)
+		renderPass.SetIndexBuffer(indexBuffer, mesh.IndexFormat, 0, wgpu.WholeSize)
+		renderPass.SetBindGroup(0, model.Materials[0].BindGroup, nil)
 		renderPass.SetBindGroup(1, cameraBindGroup, nil)
-		renderPass.DrawIndexed(mesh.NumElements, instanceCount, 0, 0, 0)
+		renderPass.DrawIndexed(numElements, instanceCount, 0, 0, 0)
 	}
 }
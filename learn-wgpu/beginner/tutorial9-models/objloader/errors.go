@@ -0,0 +1,44 @@
+package objloader
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSyntax          = errors.New("invalid syntax")
+	ErrMissingValue    = errors.New("missing value")
+	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrUnsupported     = errors.New("unsupported element")
+)
+
+// ParseError describes a malformed statement in an obj or mtl file.
+type ParseError struct {
+	// File is the name of the file, empty if unknown
+	File string
+	Line int
+	// Column is the 1-based byte offset of the offending token in the
+	// line, 0 if the statement as a whole is at fault
+	Column int
+	// Directive is the keyword of the statement, like "f" or "map_Kd"
+	Directive string
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	pos := fmt.Sprintf("line %d", e.Line)
+	if e.Column != 0 {
+		pos += fmt.Sprintf(", column %d", e.Column)
+	}
+	if e.File != "" {
+		pos = e.File + ": " + pos
+	}
+	if e.Directive == "" {
+		return fmt.Sprintf("%s: %v", pos, e.Err)
+	}
+	return fmt.Sprintf("%s: invalid %s: %v", pos, e.Directive, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
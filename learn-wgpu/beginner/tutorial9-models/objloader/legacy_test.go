@@ -0,0 +1,262 @@
+package objloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+// loadObjLegacy is the loader LoadObjReader replaced, kept to
+// benchmark against: it splits lines with strings.Split, parses with
+// strconv and deduplicates face vertices with a map. mtllib statements
+// and normal generation are left out, the benchmark grid has normals.
+func loadObjLegacy(r io.Reader) ([]Model, error) {
+	var (
+		models []Model
+
+		currentModel    string = "unnamed_object"
+		currentMaterial string
+		tmpVertices     [][3]float32
+		tmpNormals      [][3]float32
+		tmpTexCoords    [][3]float32
+		tmpFaceElems    [][3][3]int64
+		tmpFaceMtls     []string
+		currentKey      smoothingKey
+		groups          = map[string]int{}
+		lineNumber      int
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lineNumber++
+
+		l := strings.TrimSpace(s.Text())
+		split := strings.Split(l, " ")
+		if len(split) < 1 {
+			return nil, fmt.Errorf("invalid tokens at line %d", lineNumber)
+		}
+
+		switch split[0] {
+		case "o":
+			if len(split) < 2 {
+				return nil, fmt.Errorf("invalid object name at line %d", lineNumber)
+			}
+
+			name := split[1]
+			if name != currentModel && len(tmpFaceElems) != 0 {
+				models = append(models, exportModelLegacy(currentModel, tmpVertices, tmpNormals, tmpTexCoords, tmpFaceElems, tmpFaceMtls))
+				tmpFaceElems = nil
+				tmpFaceMtls = nil
+			}
+
+			currentModel = name
+
+		case "v", "vn":
+			if len(split) < 4 {
+				return nil, fmt.Errorf("invalid vertex at line %d", lineNumber)
+			}
+
+			var v [3]float32
+			for i := range v {
+				f, err := strconv.ParseFloat(split[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid vertex at line %d", lineNumber)
+				}
+				v[i] = float32(f)
+			}
+			if split[0] == "v" {
+				tmpVertices = append(tmpVertices, v)
+			} else {
+				tmpNormals = append(tmpNormals, v)
+			}
+
+		case "vt":
+			if len(split) < 2 {
+				return nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
+			}
+
+			var vt [3]float32
+			for i := 0; i < 3 && i+1 < len(split); i++ {
+				f, err := strconv.ParseFloat(split[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
+				}
+				vt[i] = float32(f)
+			}
+			tmpTexCoords = append(tmpTexCoords, vt)
+
+		case "f":
+			if len(split) < 4 {
+				return nil, fmt.Errorf("invalid face element at line %d", lineNumber)
+			}
+
+			var polygon [][3]int64
+			for _, indices := range split[1:] {
+				idx, err := parseFaceVertexLegacy(indices, len(tmpVertices), len(tmpTexCoords), len(tmpNormals))
+				if err != nil {
+					return nil, fmt.Errorf("invalid face element at line %d: %w", lineNumber, err)
+				}
+				polygon = append(polygon, idx)
+			}
+
+			positions := make([][3]float32, len(polygon))
+			for i, idx := range polygon {
+				positions[i] = tmpVertices[idx[0]-1]
+			}
+			tris, _ := triangulate(nil, nil, positions)
+			for _, tri := range tris {
+				tmpFaceElems = append(tmpFaceElems, [3][3]int64{polygon[tri[0]], polygon[tri[1]], polygon[tri[2]]})
+				tmpFaceMtls = append(tmpFaceMtls, currentMaterial)
+			}
+
+		case "s":
+			if len(split) < 2 {
+				return nil, fmt.Errorf("invalid smoothing group at line %d", lineNumber)
+			}
+			if split[1] == "off" {
+				currentKey.smoothing = 0
+				continue
+			}
+			smoothing, err := strconv.ParseUint(split[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid smoothing group at line %d", lineNumber)
+			}
+			currentKey.smoothing = uint32(smoothing)
+
+		case "g":
+			name := strings.Join(split[1:], " ")
+			group, ok := groups[name]
+			if !ok {
+				group = len(groups)
+				groups[name] = group
+			}
+			currentKey.group = group
+
+		case "usemtl":
+			if len(split) < 2 {
+				return nil, fmt.Errorf("invalid mtl reference at line %d", lineNumber)
+			}
+			currentMaterial = split[1]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	models = append(models, exportModelLegacy(currentModel, tmpVertices, tmpNormals, tmpTexCoords, tmpFaceElems, tmpFaceMtls))
+	return models, nil
+}
+
+func parseFaceVertexLegacy(s string, numVertices, numTexCoords, numNormals int) (idx [3]int64, err error) {
+	indicesSplit := strings.SplitN(s, "/", 3)
+	counts := [3]int{numVertices, numTexCoords, numNormals}
+
+	for i, is := range indicesSplit {
+		if is == "" {
+			if i == 0 {
+				return idx, fmt.Errorf("missing vertex index in %q", s)
+			}
+			continue
+		}
+
+		n, err := strconv.ParseInt(is, 10, 64)
+		if err != nil {
+			return idx, fmt.Errorf("invalid index in %q", s)
+		}
+		if n < 0 {
+			n += int64(counts[i]) + 1
+		}
+		if n < 1 || n > int64(counts[i]) {
+			return idx, fmt.Errorf("index out of range in %q", s)
+		}
+		idx[i] = n
+	}
+	return idx, nil
+}
+
+func exportModelLegacy(name string, verts, normals, texCoords [][3]float32, faces [][3][3]int64, mtls []string) (model Model) {
+	model.Name = name
+
+	for i, mtl := range mtls {
+		if n := len(model.Submeshes); n == 0 || model.Submeshes[n-1].MaterialName != mtl {
+			model.Submeshes = append(model.Submeshes, Submesh{MaterialName: mtl, IndexOffset: uint32(i * 3)})
+		}
+		model.Submeshes[len(model.Submeshes)-1].IndexCount += 3
+	}
+
+	hasTexCoords, hasNormals := len(faces) != 0, len(faces) != 0
+	for _, face := range faces {
+		for _, idx := range face {
+			hasTexCoords = hasTexCoords && idx[1] != 0
+			hasNormals = hasNormals && idx[2] != 0
+		}
+	}
+
+	indexMap := map[[3]int64]uint32{}
+	for _, face := range faces {
+		for _, idx := range face {
+			if !hasTexCoords {
+				idx[1] = 0
+			}
+			if !hasNormals {
+				idx[2] = 0
+			}
+
+			if i, ok := indexMap[idx]; ok {
+				model.Indices = append(model.Indices, i)
+				continue
+			}
+
+			model.Vertices = append(model.Vertices, verts[idx[0]-1])
+			if hasTexCoords {
+				model.TextureCoords = append(model.TextureCoords, texCoords[idx[1]-1])
+			}
+			if hasNormals {
+				model.Normals = append(model.Normals, normals[idx[2]-1])
+			}
+			next := uint32(len(indexMap))
+			model.Indices = append(model.Indices, next)
+			indexMap[idx] = next
+		}
+	}
+	return
+}
+
+// TestLoadObjLegacy checks that both loaders build the same model from
+// the benchmark grid, so the benchmarks compare the same work.
+func TestLoadObjLegacy(t *testing.T) {
+	grid := generateGrid(20)
+	legacy, err := loadObjLegacy(bytes.NewReader(grid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	models, _, err := LoadObjReader(bytes.NewReader(grid), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := legacy[0], models[0]
+	if !slices.Equal(got.Vertices, want.Vertices) || !slices.Equal(got.TextureCoords, want.TextureCoords) ||
+		!slices.Equal(got.Normals, want.Normals) || !slices.Equal(got.Indices, want.Indices) {
+		t.Errorf("LoadObjReader: %d vertices and %d indices, legacy loader %d and %d",
+			len(got.Vertices), len(got.Indices), len(want.Vertices), len(want.Indices))
+	}
+}
+
+func BenchmarkLoadObjLegacy(b *testing.B) {
+	benchGridOnce.Do(func() { benchGrid = generateGrid(benchGridSize) })
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchGrid)))
+	for i := 0; i < b.N; i++ {
+		if _, err := loadObjLegacy(bytes.NewReader(benchGrid)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package objloader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+type Material struct {
+	Name              string
+	Ambient           [3]float32
+	Diffuse           [3]float32
+	Specular          [3]float32
+	Emissive          [3]float32
+	Shininess         float32
+	Dissolve          float32
+	OpticalDensity    float32
+	AmbientTexture    TextureRef
+	DiffuseTexture    TextureRef
+	SpecularTexture   TextureRef
+	EmissiveTexture   TextureRef
+	NormalTexture     TextureRef
+	ShininessTexture  TextureRef
+	DissolveTexture   TextureRef
+	IlluminationModel uint8
+
+	// PBR extension
+	Roughness          float32
+	Metallic           float32
+	Sheen              float32
+	ClearcoatThickness float32
+	ClearcoatRoughness float32
+	Anisotropy         float32
+	AnisotropyRotation float32
+	RoughnessTexture   TextureRef
+	MetallicTexture    TextureRef
+	SheenTexture       TextureRef
+}
+
+// TextureRef is a texture map statement. Path is empty if the map is
+// not set. The zero value of every option is its default, so a
+// TextureRef with only a Path is a plain texture.
+type TextureRef struct {
+	Path string
+	// Scale, Offset and Turbulence apply to the texture coordinates
+	// (-s, -o and -t), a zero Scale is the default 1 1 1
+	Scale      [3]float32
+	Offset     [3]float32
+	Turbulence [3]float32
+	// BumpMultiplier scales the values of bump maps (-bm), zero is the
+	// default 1
+	BumpMultiplier float32
+	// Clamp restricts texture coordinates to [0, 1] (-clamp)
+	Clamp bool
+	// NoBlendU and NoBlendV disable horizontal and vertical texture
+	// blending (-blendu off and -blendv off)
+	NoBlendU bool
+	NoBlendV bool
+	// Boost sharpens mip-mapped textures (-boost)
+	Boost float32
+	// Base and Gain modify the texture values, value*Gain + Base (-mm),
+	// a zero Gain is the default 1
+	Base float32
+	Gain float32
+	// Channel is the channel used for scalar textures, one of r, g, b,
+	// m, l or z (-imfchan)
+	Channel string
+	// Resolution is the resolution the texture is created with
+	// (-texres)
+	Resolution int
+}
+
+// parseTextureRef parses the options and file name following a texture
+// map keyword. The file name is the rest of the line after the
+// options, so it may contain spaces.
+func parseTextureRef(t *tokenizer) (TextureRef, error) {
+	var ref TextureRef
+
+	for {
+		start := *t
+		option := t.next()
+		if option == nil {
+			return ref, ErrMissingValue
+		}
+		if option[0] != '-' {
+			*t = start
+			break
+		}
+
+		var err error
+		switch string(option) {
+		case "-s":
+			// omitted components keep the default 1
+			ref.Scale = [3]float32{1, 1, 1}
+			err = optionFloat32s(t, ref.Scale[:], 1)
+		case "-o":
+			err = optionFloat32s(t, ref.Offset[:], 1)
+		case "-t":
+			err = optionFloat32s(t, ref.Turbulence[:], 1)
+		case "-bm":
+			ref.BumpMultiplier, err = optionFloat32(t)
+		case "-boost":
+			ref.Boost, err = optionFloat32(t)
+		case "-mm":
+			mm := [2]float32{0, 1}
+			err = optionFloat32s(t, mm[:], 1)
+			ref.Base, ref.Gain = mm[0], mm[1]
+		case "-clamp":
+			ref.Clamp, err = optionBool(t)
+		case "-blendu":
+			var blend bool
+			blend, err = optionBool(t)
+			ref.NoBlendU = !blend
+		case "-blendv":
+			var blend bool
+			blend, err = optionBool(t)
+			ref.NoBlendV = !blend
+		case "-imfchan":
+			channel := t.next()
+			if channel == nil {
+				err = ErrMissingValue
+			}
+			ref.Channel = string(channel)
+		case "-texres":
+			var res int64
+			res, err = parseIntBytes(t.next())
+			ref.Resolution = int(res)
+		default:
+			// unknown options are skipped along with their numeric
+			// arguments
+			err = optionFloat32s(t, make([]float32, 3), 0)
+		}
+		if err != nil {
+			return ref, fmt.Errorf("option %s: %w", option, err)
+		}
+	}
+
+	ref.Path = string(t.rest())
+	return ref, nil
+}
+
+// optionFloat32s reads up to len(dst) numeric option arguments into
+// dst, requiring at least min of them.
+func optionFloat32s(t *tokenizer, dst []float32, min int) error {
+	for i := range dst {
+		start := *t
+		field := t.next()
+		if field == nil {
+			break
+		}
+
+		f, err := parseFloatBytes(field)
+		if err != nil {
+			*t = start
+			break
+		}
+		dst[i] = f
+		min--
+	}
+	if min > 0 {
+		return ErrMissingValue
+	}
+	return nil
+}
+
+func optionFloat32(t *tokenizer) (float32, error) {
+	var v [1]float32
+	err := optionFloat32s(t, v[:], 1)
+	return v[0], err
+}
+
+func optionBool(t *tokenizer) (bool, error) {
+	switch string(t.next()) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	default:
+		return false, ErrSyntax
+	}
+}
+
+// loadMtl loads the materials of the mtl data read from r, file is
+// only used in errors.
+func loadMtl(r io.Reader, file string, opts *LoadOptions) ([]Material, error) {
+	var materials []Material
+
+	var (
+		currentMtl = Material{Name: "unnamed_mtl"}
+		lineNumber int
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lineNumber++
+
+		t := tokenizer{line: s.Bytes()}
+		keyword := t.next()
+
+		if err := parseMtlLine(&t, keyword, &currentMtl, &materials); err != nil {
+			perr := &ParseError{
+				File:      file,
+				Line:      lineNumber,
+				Column:    t.col,
+				Directive: string(keyword),
+				Err:       err,
+			}
+			if !opts.Lenient {
+				return nil, perr
+			}
+			if opts.Warn != nil {
+				opts.Warn(perr)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	materials = append(materials, currentMtl)
+
+	return materials, nil
+}
+
+func parseMtlLine(t *tokenizer, keyword []byte, currentMtl *Material, materials *[]Material) error {
+	var (
+		color   *[3]float32
+		scalar  *float32
+		texture *TextureRef
+	)
+
+	switch string(keyword) {
+	case "newmtl":
+		name := t.next()
+		if name == nil {
+			return ErrMissingValue
+		}
+
+		if string(name) != currentMtl.Name {
+			zero := Material{Name: "unnamed_mtl"}
+			if *currentMtl != zero {
+				*materials = append(*materials, *currentMtl)
+			}
+
+			*currentMtl = Material{Name: string(name)}
+		}
+
+	case "Ka": // ambient
+		color = &currentMtl.Ambient
+	case "Kd": // diffuse
+		color = &currentMtl.Diffuse
+	case "Ks": // specular
+		color = &currentMtl.Specular
+	case "Ke": // emissive
+		color = &currentMtl.Emissive
+
+	case "Ns": // shininess
+		scalar = &currentMtl.Shininess
+	case "Ni": // optical_density
+		scalar = &currentMtl.OpticalDensity
+	case "d": // dissolve
+		scalar = &currentMtl.Dissolve
+	case "Pr": // roughness
+		scalar = &currentMtl.Roughness
+	case "Pm": // metallic
+		scalar = &currentMtl.Metallic
+	case "Ps": // sheen
+		scalar = &currentMtl.Sheen
+	case "Pc": // clearcoat_thickness
+		scalar = &currentMtl.ClearcoatThickness
+	case "Pcr": // clearcoat_roughness
+		scalar = &currentMtl.ClearcoatRoughness
+	case "aniso": // anisotropy
+		scalar = &currentMtl.Anisotropy
+	case "anisor": // anisotropy_rotation
+		scalar = &currentMtl.AnisotropyRotation
+
+	case "map_Ka": // ambient_texture
+		texture = &currentMtl.AmbientTexture
+	case "map_Kd": // diffuse_texture
+		texture = &currentMtl.DiffuseTexture
+	case "map_Ks": // specular_texture
+		texture = &currentMtl.SpecularTexture
+	case "map_Ke": // emissive_texture
+		texture = &currentMtl.EmissiveTexture
+	case "map_Bump", "map_bump", "bump", "norm": // normal_texture
+		texture = &currentMtl.NormalTexture
+	case "map_Ns", "map_ns", "map_NS": // shininess_texture
+		texture = &currentMtl.ShininessTexture
+	case "map_d": // dissolve_texture
+		texture = &currentMtl.DissolveTexture
+	case "map_Pr": // roughness_texture
+		texture = &currentMtl.RoughnessTexture
+	case "map_Pm": // metallic_texture
+		texture = &currentMtl.MetallicTexture
+	case "map_Ps": // sheen_texture
+		texture = &currentMtl.SheenTexture
+
+	case "illum": // illumination_model
+		x, err := parseIntBytes(t.next())
+		if err != nil {
+			return err
+		}
+		if x < 0 || x > 255 {
+			return ErrSyntax
+		}
+
+		currentMtl.IlluminationModel = uint8(x)
+
+	default: // comment or unknown (ignored)
+	}
+
+	switch {
+	case color != nil:
+		var v [3]float32
+		n, err := t.float32s(v[:])
+		if err != nil {
+			return err
+		}
+		if n < 3 {
+			return ErrMissingValue
+		}
+		*color = v
+
+	case scalar != nil:
+		var v [1]float32
+		n, err := t.float32s(v[:])
+		if err != nil {
+			return err
+		}
+		if n < 1 {
+			return ErrMissingValue
+		}
+		*scalar = v[0]
+
+	case texture != nil:
+		ref, err := parseTextureRef(t)
+		if err != nil {
+			return err
+		}
+
+		*texture = ref
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package objloader
+
+import (
+	"math"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+// NormalMode selects how normals are generated for models that have
+// none.
+type NormalMode int
+
+const (
+	// NormalsNone leaves Model.Normals empty.
+	NormalsNone NormalMode = iota
+	// NormalsFlat gives every face corner the face normal.
+	NormalsFlat
+	// NormalsSmooth averages the normals of adjacent faces in the same
+	// smoothing group, weighted by face area and corner angle. Faces
+	// with smoothing turned off ("s off" or "s 0") stay flat. Faces
+	// before the first s statement are smoothed in a group of their
+	// own, so files without smoothing groups, such as scans, come out
+	// smooth.
+	NormalsSmooth
+)
+
+// smoothingKey identifies the group a face is smoothed in. Faces only
+// share normals if both the "g" group and the "s" smoothing group
+// match.
+type smoothingKey struct {
+	group     int
+	smoothing uint32
+	// implicit is set for the faces before the first s statement
+	implicit bool
+}
+
+func (k smoothingKey) smoothed() bool {
+	return k.smoothing != 0 || k.implicit
+}
+
+// generateNormals computes a normal for every face corner and returns
+// the deduplicated normals along with faces whose normal indices
+// reference them.
+func generateNormals(verts [][3]float32, faces [][3][3]int32, keys []smoothingKey, opts *LoadOptions) ([][3]float32, [][3][3]int32) {
+	faceNormals := make([]glm.Vec3[float32], len(faces))
+	cornerWeights := make([][3]float32, len(faces))
+
+	for i, face := range faces {
+		a := glm.Vec3[float32](verts[face[0][0]-1])
+		b := glm.Vec3[float32](verts[face[1][0]-1])
+		c := glm.Vec3[float32](verts[face[2][0]-1])
+
+		n := b.Sub(a).Cross(c.Sub(a))
+		// the magnitude of the cross product is twice the face area
+		area := n.Magnitude() / 2
+		if area != 0 {
+			faceNormals[i] = n.DivScalar(2 * area)
+		}
+
+		cornerWeights[i] = [3]float32{
+			area * cornerAngle(a, b, c),
+			area * cornerAngle(b, c, a),
+			area * cornerAngle(c, a, b),
+		}
+	}
+
+	type corner struct{ face, vertex int }
+	type sharedKey struct {
+		position int32
+		key      smoothingKey
+	}
+
+	var shared map[sharedKey][]corner
+	if opts.GenerateNormals == NormalsSmooth {
+		shared = map[sharedKey][]corner{}
+		for i, face := range faces {
+			if !keys[i].smoothed() {
+				continue
+			}
+			for j, idx := range face {
+				k := sharedKey{idx[0], keys[i]}
+				shared[k] = append(shared[k], corner{i, j})
+			}
+		}
+	}
+
+	var cosCrease float32 = -1
+	if opts.CreaseAngle != 0 {
+		cosCrease = float32(math.Cos(float64(opts.CreaseAngle)))
+	}
+
+	var (
+		normals   [][3]float32
+		normalMap = map[glm.Vec3[float32]]int32{}
+		out       = make([][3][3]int32, len(faces))
+	)
+
+	for i, face := range faces {
+		for j, idx := range face {
+			n := faceNormals[i]
+
+			if shared != nil && keys[i].smoothed() {
+				var sum glm.Vec3[float32]
+				for _, c := range shared[sharedKey{idx[0], keys[i]}] {
+					other := faceNormals[c.face]
+					if other.Dot(faceNormals[i]) < cosCrease {
+						continue
+					}
+					sum = sum.Add(other.MulScalar(cornerWeights[c.face][c.vertex]))
+				}
+				if sum.Magnitude() != 0 {
+					n = sum.Normalize()
+				}
+			}
+
+			ni, ok := normalMap[n]
+			if !ok {
+				normals = append(normals, [3]float32(n))
+				ni = int32(len(normals))
+				normalMap[n] = ni
+			}
+
+			out[i][j] = [3]int32{idx[0], idx[1], ni}
+		}
+	}
+
+	return normals, out
+}
+
+// cornerAngle returns the angle at a in the triangle abc.
+func cornerAngle(a, b, c glm.Vec3[float32]) float32 {
+	ab, ac := b.Sub(a), c.Sub(a)
+	l := ab.Magnitude() * ac.Magnitude()
+	if l == 0 {
+		return 0
+	}
+
+	cos := float64(ab.Dot(ac) / l)
+	return float32(math.Acos(math.Max(-1, math.Min(1, cos))))
+}
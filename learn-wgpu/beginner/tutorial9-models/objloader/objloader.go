@@ -1,453 +1,581 @@
 package objloader
 
 import (
-	"bufio"
 	"embed"
-	"fmt"
+	"io"
 	"io/fs"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"sort"
 )
 
 type Model struct {
-	Name          string
-	MaterialName  string
+	Name string
+	// Submeshes splits Indices into ranges drawn with the same
+	// material, a new one is started at every usemtl switch.
+	Submeshes     []Submesh
 	Vertices      [][3]float32
 	TextureCoords [][3]float32
 	Normals       [][3]float32
-	Indices       []uint32
+	// Colors is empty unless every vertex has a colour.
+	Colors [][3]float32
+	// LinearColors is set if Colors are linear, as in glTF, instead
+	// of sRGB encoded as in the other formats.
+	LinearColors bool
+	// Tangents is empty unless filled in by meshutil.GenerateTangents
+	// or loaded from another format.
+	Tangents [][4]float32
+	// Joints are the indices of the four joints influencing a vertex
+	// and Weights their weights, both are empty unless loaded from a
+	// format with skinning.
+	Joints  [][4]uint16
+	Weights [][4]float32
+	// Indices is a triangle list.
+	Indices []uint32
+	// LineIndices is a line list of the l statements, PointIndices a
+	// point list of the p statements. They share the vertices of the
+	// triangles but not their submeshes.
+	LineIndices  []uint32
+	PointIndices []uint32
 }
 
-type Material struct {
-	Name              string
-	Ambient           [3]float32
-	Diffuse           [3]float32
-	Specular          [3]float32
-	Shininess         float32
-	Dissolve          float32
-	OpticalDensity    float32
-	AmbientTexture    string
-	DiffuseTexture    string
-	SpecularTexture   string
-	NormalTexture     string
-	ShininessTexture  string
-	DissolveTexture   string
-	IlluminationModel uint8
+type Submesh struct {
+	MaterialName string
+	IndexOffset  uint32
+	IndexCount   uint32
 }
 
-func LoadObj(dir fs.FS, obj string) ([]Model, []Material, error) {
+// LoadOptions controls optional processing done by LoadObj.
+type LoadOptions struct {
+	// GenerateNormals is used for models without normals.
+	GenerateNormals NormalMode
+	// CreaseAngle in radians, when non-zero, keeps adjacent faces
+	// whose normals differ by more than it from being smoothed
+	// together.
+	CreaseAngle float32
+	// Concurrency is the number of goroutines parsing the input in
+	// parallel, values below 2 parse it sequentially.
+	Concurrency int
+	// Lenient skips malformed statements and faces referencing missing
+	// elements instead of failing, passing each of them to Warn.
+	Lenient bool
+	Warn    func(warning *ParseError)
+}
+
+// LoadObj loads the models and materials of the obj file, resolving
+// mtllib references relative to it. opts may be nil, in which case no
+// optional processing is done.
+func LoadObj(dir fs.FS, obj string, opts *LoadOptions) ([]Model, []Material, error) {
 	f, err := dir.Open(obj)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer f.Close()
 
-	var (
-		models    []Model
-		materials []Material
-	)
-
-	var (
-		currentModel    string = "unnamed_object"
-		currentMaterial string
-		tmpVertices     [][3]float32
-		tmpNormals      [][3]float32
-		tmpTexCoords    [][3]float32
-		tmpFaceElems    [][3][3]int64
-		lineNumber      int
-	)
-
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		lineNumber++
+	return loadObj(f, obj, FSMaterialResolver(dir, obj), opts)
+}
 
-		l := strings.TrimSpace(s.Text())
-		split := strings.Split(l, " ")
-		if len(split) < 1 {
-			return nil, nil, fmt.Errorf("invalid tokens at line %d", lineNumber)
+// MaterialResolver opens the .mtl file referenced by a mtllib
+// statement.
+type MaterialResolver func(mtllib string) (io.ReadCloser, error)
+
+// FSMaterialResolver resolves mtllib references relative to the obj
+// file in dir.
+func FSMaterialResolver(dir fs.FS, obj string) MaterialResolver {
+	return func(mtllib string) (io.ReadCloser, error) {
+		var mtlFile string
+		if _, ok := dir.(embed.FS); ok {
+			mtlFile = filepath.Dir(obj) + "/" + mtllib
+		} else {
+			mtlFile = filepath.Join(filepath.Dir(obj), mtllib)
 		}
 
-		switch split[0] {
-		case "o":
-			if len(split) < 2 {
-				return nil, nil, fmt.Errorf("invalid object name at line %d", lineNumber)
-			}
-
-			name := split[1]
-			if name != currentModel && len(tmpVertices) != 0 {
-				model := exportModel(
-					currentModel,
-					tmpVertices,
-					tmpNormals,
-					tmpTexCoords,
-					tmpFaceElems,
-				)
-				model.MaterialName = currentMaterial
-				models = append(models, model)
-			}
-
-			currentModel = name
-
-		case "v":
-			if len(split) < 4 {
-				return nil, nil, fmt.Errorf("invalid vertex at line %d", lineNumber)
-			}
-
-			x, y, z, err := parse3Float(split[1], split[2], split[3])
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid vertex at line %d", lineNumber)
-			}
-			tmpVertices = append(tmpVertices, [3]float32{x, y, z})
-
-		case "vn":
-			if len(split) < 4 {
-				return nil, nil, fmt.Errorf("invalid vertex normal at line %d", lineNumber)
-			}
-
-			i, j, k, err := parse3Float(split[1], split[2], split[3])
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid vertex normal at line %d", lineNumber)
-			}
-			tmpNormals = append(tmpNormals, [3]float32{i, j, k})
+		return dir.Open(mtlFile)
+	}
+}
 
-		case "vt":
-			if len(split) < 2 {
-				return nil, nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
-			}
+// LoadObjReader loads the models and materials of the obj data read
+// from r. resolve may be nil, in which case mtllib statements are
+// ignored. opts may be nil, in which case no optional processing is
+// done.
+func LoadObjReader(r io.Reader, resolve MaterialResolver, opts *LoadOptions) ([]Model, []Material, error) {
+	return loadObj(r, "", resolve, opts)
+}
 
-			u, err := strconv.ParseFloat(split[1], 32)
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
-			}
-			v := float64(0)
-			if len(split) >= 3 {
-				v, err = strconv.ParseFloat(split[2], 32)
-				if err != nil {
-					return nil, nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
-				}
-			}
-			w := float64(0)
-			if len(split) >= 4 {
-				w, err = strconv.ParseFloat(split[3], 32)
-				if err != nil {
-					return nil, nil, fmt.Errorf("invalid texture coordinates at line %d", lineNumber)
-				}
-			}
+func loadObj(r io.Reader, file string, resolve MaterialResolver, opts *LoadOptions) ([]Model, []Material, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
 
-			tmpTexCoords = append(tmpTexCoords, [3]float32{float32(u), float32(v), float32(w)})
+	l := &loader{
+		file:         file,
+		opts:         opts,
+		resolve:      resolve,
+		currentModel: "unnamed_object",
+		currentKey:   smoothingKey{implicit: true},
+		groups:       map[string]int{},
+	}
 
-		case "f", "l":
-			if len(split) < 2 {
-				return nil, nil, fmt.Errorf("invalid face/line element at line %d", lineNumber)
-			}
+	if opts.Concurrency < 2 {
+		var err error
+		readErr := readChunks(r, false, func(data []byte, firstLine int) bool {
+			err = l.merge(parseChunk(data, firstLine, opts.Lenient))
+			return err == nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+	} else if err := l.mergeConcurrently(r, opts.Concurrency); err != nil {
+		return nil, nil, err
+	}
 
-			switch len(split) - 1 {
-			case 1: // point
-				fallthrough
-			case 2: // line
-				fallthrough
-			case 4: // quad
-				fallthrough
-			default: // polygon
-				return nil, nil, fmt.Errorf("unsupported face/line element at line %d", lineNumber)
-
-			case 3: // triangle
-				var indicesArr [3][3]int64
-
-				for i, indices := range split[1:] {
-					indicesSplit := strings.SplitN(indices, "/", 3)
-					if len(indicesSplit) != 3 {
-						return nil, nil, fmt.Errorf("unsupported face/line element at line %d", lineNumber)
-					}
+	l.models = append(l.models, l.exportModel())
 
-					vIdx, err := strconv.ParseInt(indicesSplit[0], 10, 64)
-					if err != nil {
-						return nil, nil, fmt.Errorf("invalid face/line element at line %d", lineNumber)
-					}
-					vtIdx, err := strconv.ParseInt(indicesSplit[1], 10, 64)
-					if err != nil {
-						return nil, nil, fmt.Errorf("invalid face/line element at line %d", lineNumber)
-					}
-					vnIdx, err := strconv.ParseInt(indicesSplit[2], 10, 64)
-					if err != nil {
-						return nil, nil, fmt.Errorf("invalid face/line element at line %d", lineNumber)
-					}
+	return l.models, l.materials, nil
+}
 
-					indicesArr[i] = [3]int64{vIdx, vtIdx, vnIdx}
-				}
+// loader holds the state of the obj file read so far. Chunks are
+// parsed independently of each other and merged into it in order.
+type loader struct {
+	file    string
+	opts    *LoadOptions
+	resolve MaterialResolver
+	// warnings found while merging the current chunk
+	warnings []*ParseError
+
+	models    []Model
+	materials []Material
+
+	currentModel    string
+	currentMaterial string
+	currentKey      smoothingKey
+	groups          map[string]int
+
+	vertices  [][3]float32
+	normals   [][3]float32
+	texCoords [][3]float32
+	colors    [][3]float32
+	colored   []bool
+
+	// faces, lines and points of the current model
+	faces     [][3][3]int32
+	faceKeys  []smoothingKey
+	faceMtls  []string
+	lines     [][2][3]int32
+	points    [][3]int32
+	polygon   [][3]int32
+	positions [][3]float32
+	projected [][2]float64
+	triangles [][3]int
+	cache     vertexCache
+}
 
-				tmpFaceElems = append(tmpFaceElems, indicesArr)
-			}
+// mergeConcurrently parses chunks of r on up to workers goroutines
+// and merges them in order.
+func (l *loader) mergeConcurrently(r io.Reader, workers int) error {
+	var (
+		results = make(chan chan *chunk, workers)
+		done    = make(chan struct{})
+		readErr error
+	)
 
-		case "mtllib":
-			if len(split) < 2 {
-				return nil, nil, fmt.Errorf("invalid external .mtl reference at line %d", lineNumber)
-			}
+	go func() {
+		defer close(results)
 
-			mtl := split[1]
-			mtls, err := loadMtl(dir, obj, mtl)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to load mtl file at line %d: %w", lineNumber, err)
+		readErr = readChunks(r, true, func(data []byte, firstLine int) bool {
+			result := make(chan *chunk, 1)
+			select {
+			case results <- result:
+			case <-done:
+				return false
 			}
 
-			materials = append(materials, mtls...)
+			go func() { result <- parseChunk(data, firstLine, l.opts.Lenient) }()
+			return true
+		})
+	}()
 
-		case "usemtl":
-			if len(split) < 2 {
-				return nil, nil, fmt.Errorf("invalid mtl reference at line %d", lineNumber)
+	for result := range results {
+		if err := l.merge(<-result); err != nil {
+			close(done)
+			for range results {
 			}
-
-			currentMaterial = split[1]
+			return err
 		}
 	}
 
-	model := exportModel(
-		currentModel,
-		tmpVertices,
-		tmpNormals,
-		tmpTexCoords,
-		tmpFaceElems,
-	)
-	models = append(models, model)
-
-	return models, materials, nil
-}
-
-func parseFloat(s string, bitSize int) (float32, error) {
-	r, err := strconv.ParseFloat(s, bitSize)
-	return float32(r), err
+	return readErr
 }
 
-func parse3Float(xs, ys, zs string) (x float32, y float32, z float32, err error) {
-	x, err = parseFloat(xs, 32)
-	if err != nil {
-		return 0, 0, 0, err
-	}
-	y, err = parseFloat(ys, 32)
-	if err != nil {
-		return 0, 0, 0, err
+func (l *loader) merge(c *chunk) error {
+	base := [3]int64{int64(len(l.vertices)), int64(len(l.texCoords)), int64(len(l.normals))}
+
+	l.vertices = append(l.vertices, c.vertices...)
+	l.texCoords = append(l.texCoords, c.texCoords...)
+	l.normals = append(l.normals, c.normals...)
+	if len(c.colors) != 0 || len(l.colors) != 0 {
+		l.padColors(int(base[0]))
+		l.colors = append(l.colors, c.colors...)
+		l.colored = append(l.colored, c.colored...)
+		l.padColors(len(l.vertices))
 	}
-	z, err = parseFloat(zs, 32)
-	if err != nil {
-		return 0, 0, 0, err
-	}
-	return
-}
-
-func exportModel(name string, verts [][3]float32, normals [][3]float32, texCoords [][3]float32, faces [][3][3]int64) (model Model) {
-	model.Name = name
 
-	indexMap := map[[3]int64]uint32{}
+	refs, faces := c.refs, c.faces
+	mergeFaces := func(n int) error {
+	faces:
+		for _, f := range faces[:n] {
+			polygonRefs := refs[:f.refCount]
+			refs = refs[f.refCount:]
+
+			polygon := l.polygon[:0]
+			for _, ref := range polygonRefs {
+				var idx [3]int32
+				for i, raw := range ref {
+					if raw == 0 {
+						// absent attribute
+						continue
+					}
 
-	for _, face := range faces {
-		for _, idx := range face {
-			vIdx := idx[0] - 1
-			vtIdx := idx[1] - 1
-			vnIdx := idx[2] - 1
+					count := base[i] + int64(f.counts[i])
+					abs := int64(raw)
+					if abs < 0 {
+						abs += count + 1
+					}
+					if abs < 1 || abs > count {
+						err := l.report(&ParseError{Line: f.line, Directive: string(f.keyword), Err: ErrIndexOutOfRange})
+						if err != nil {
+							return err
+						}
+						continue faces
+					}
+					idx[i] = int32(abs)
+				}
+				polygon = append(polygon, idx)
+			}
+			l.polygon = polygon
 
-			if i, ok := indexMap[idx]; ok {
-				model.Indices = append(model.Indices, i)
-				continue
-			} else {
-				model.Vertices = append(model.Vertices, verts[vIdx])
-				model.Normals = append(model.Normals, normals[vnIdx])
-				model.TextureCoords = append(model.TextureCoords, texCoords[vtIdx])
-				next := len(indexMap)
-				model.Indices = append(model.Indices, uint32(next))
-				indexMap[idx] = uint32(next)
+			switch {
+			case f.keyword == 'p' || len(polygon) == 1:
+				l.points = append(l.points, polygon...)
+			case f.keyword == 'l' || len(polygon) == 2:
+				for i := 1; i < len(polygon); i++ {
+					l.lines = append(l.lines, [2][3]int32{polygon[i-1], polygon[i]})
+				}
+			default:
+				l.addPolygon(polygon)
 			}
 		}
-	}
-	return
-}
-
-func loadMtl(dir fs.FS, obj string, mtl string) ([]Material, error) {
-	var mtlFile string
-	if _, ok := dir.(embed.FS); ok {
-		mtlFile = filepath.Dir(obj) + "/" + mtl
-	} else {
-		mtlFile = filepath.Join(filepath.Dir(obj), mtl)
+		faces = faces[n:]
+		return nil
 	}
 
-	f, err := dir.Open(mtlFile)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	var materials []Material
-
-	var (
-		currentMtl = Material{Name: "unnamed_mtl"}
-		lineNumber int
-	)
-
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		lineNumber++
-
-		l := strings.TrimSpace(s.Text())
-		split := strings.Split(l, " ")
-		if len(split) < 1 {
-			return nil, fmt.Errorf("invalid tokens at line %d", lineNumber)
+	merged := 0
+	for _, e := range c.elements {
+		if err := mergeFaces(e.faces - merged); err != nil {
+			return err
 		}
+		merged = e.faces
 
-		switch split[0] {
-		case "newmtl":
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid material name at line %d", lineNumber)
-			}
-
-			name := split[1]
-			if name != currentMtl.Name {
-				zero := Material{Name: "unnamed_mtl"}
-				if currentMtl != zero {
-					materials = append(materials, currentMtl)
-				}
+		switch e.kind {
+		case elementObject:
+			if e.name != l.currentModel && len(l.faces)+len(l.lines)+len(l.points) != 0 {
+				l.models = append(l.models, l.exportModel())
 
-				currentMtl = Material{Name: name}
+				// vertex data is shared by all objects in the file
+				l.faces = l.faces[:0]
+				l.faceKeys = l.faceKeys[:0]
+				l.faceMtls = l.faceMtls[:0]
+				l.lines = l.lines[:0]
+				l.points = l.points[:0]
 			}
 
-		case "Ka": // ambient
-			if len(split) < 4 {
-				return nil, fmt.Errorf("invalid Ka at line %d", lineNumber)
-			}
+			l.currentModel = e.name
 
-			x, y, z, err := parse3Float(split[1], split[2], split[3])
-			if err != nil {
-				return nil, fmt.Errorf("invalid Ka at line %d", lineNumber)
-			}
+		case elementSmoothing:
+			l.currentKey.smoothing, l.currentKey.implicit = e.smoothing, false
 
-			currentMtl.Ambient = [3]float32{x, y, z}
+		case elementGroup:
+			group, ok := l.groups[e.name]
+			if !ok {
+				group = len(l.groups)
+				l.groups[e.name] = group
+			}
+			l.currentKey.group = group
 
-		case "Kd": // diffuse
-			if len(split) < 4 {
-				return nil, fmt.Errorf("invalid Kd at line %d", lineNumber)
+		case elementMtllib:
+			if l.resolve == nil {
+				continue
 			}
 
-			x, y, z, err := parse3Float(split[1], split[2], split[3])
+			mtls, err := l.loadMtl(e.name)
 			if err != nil {
-				return nil, fmt.Errorf("invalid Kd at line %d", lineNumber)
+				err := l.report(&ParseError{Line: e.line, Column: e.column, Directive: "mtllib", Err: err})
+				if err != nil {
+					return err
+				}
+				continue
 			}
 
-			currentMtl.Diffuse = [3]float32{x, y, z}
+			l.materials = append(l.materials, mtls...)
 
-		case "Ks": // specular
-			if len(split) < 4 {
-				return nil, fmt.Errorf("invalid Ks at line %d", lineNumber)
-			}
+		case elementMaterial:
+			l.currentMaterial = e.name
+		}
+	}
 
-			x, y, z, err := parse3Float(split[1], split[2], split[3])
-			if err != nil {
-				return nil, fmt.Errorf("invalid Ks at line %d", lineNumber)
-			}
+	if err := mergeFaces(len(faces)); err != nil {
+		return err
+	}
 
-			currentMtl.Specular = [3]float32{x, y, z}
+	if c.err != nil {
+		c.err.File = l.file
+		return c.err
+	}
 
-		case "Ns": // shininess
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid Ns at line %d", lineNumber)
-			}
+	l.flushWarnings(c.warnings)
+	return nil
+}
 
-			x, err := parseFloat(split[1], 32)
-			if err != nil {
-				return nil, fmt.Errorf("invalid Ns at line %d", lineNumber)
-			}
+// report returns err in strict mode, in lenient mode it is kept as a
+// warning instead.
+func (l *loader) report(err *ParseError) error {
+	err.File = l.file
+	if !l.opts.Lenient {
+		return err
+	}
 
-			currentMtl.Shininess = x
+	l.warnings = append(l.warnings, err)
+	return nil
+}
 
-		case "Ni": // optical_density
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid Ni at line %d", lineNumber)
-			}
+// flushWarnings passes the warnings of a chunk, both from parsing and
+// merging it, to the Warn callback in line order.
+func (l *loader) flushWarnings(parsed []*ParseError) {
+	warnings := append(parsed, l.warnings...)
+	l.warnings = l.warnings[:0]
 
-			x, err := parseFloat(split[1], 32)
-			if err != nil {
-				return nil, fmt.Errorf("invalid Ni at line %d", lineNumber)
-			}
+	if l.opts.Warn == nil {
+		return
+	}
 
-			currentMtl.OpticalDensity = x
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+	for _, w := range warnings {
+		w.File = l.file
+		l.opts.Warn(w)
+	}
+}
 
-		case "d": // dissolve
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid d at line %d", lineNumber)
-			}
+func (l *loader) loadMtl(mtllib string) ([]Material, error) {
+	f, err := l.resolve(mtllib)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
-			x, err := parseFloat(split[1], 32)
-			if err != nil {
-				return nil, fmt.Errorf("invalid d at line %d", lineNumber)
-			}
+	return loadMtl(f, mtllib, l.opts)
+}
 
-			currentMtl.Dissolve = x
+// padColors marks the vertices up to n without a colour as
+// uncoloured.
+func (l *loader) padColors(n int) {
+	for len(l.colors) < n {
+		l.colors = append(l.colors, [3]float32{})
+		l.colored = append(l.colored, false)
+	}
+}
 
-		case "map_Ka": // ambient_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_Ka at line %d", lineNumber)
-			}
+func (l *loader) addPolygon(polygon [][3]int32) {
+	if len(polygon) == 3 {
+		l.addFace([3][3]int32{polygon[0], polygon[1], polygon[2]})
+		return
+	}
 
-			currentMtl.AmbientTexture = split[1]
+	positions := l.positions[:0]
+	for _, idx := range polygon {
+		positions = append(positions, l.vertices[idx[0]-1])
+	}
+	l.positions = positions
 
-		case "map_Kd": // diffuse_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_Kd at line %d", lineNumber)
-			}
+	l.triangles, l.projected = triangulate(l.triangles[:0], l.projected, positions)
+	for _, tri := range l.triangles {
+		l.addFace([3][3]int32{polygon[tri[0]], polygon[tri[1]], polygon[tri[2]]})
+	}
+}
 
-			currentMtl.DiffuseTexture = split[1]
+func (l *loader) addFace(face [3][3]int32) {
+	l.faces = append(l.faces, face)
+	l.faceKeys = append(l.faceKeys, l.currentKey)
+	l.faceMtls = append(l.faceMtls, l.currentMaterial)
+}
 
-		case "map_Ks": // specular_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_Ks at line %d", lineNumber)
-			}
+// exportModel builds an indexed model from the faces, lines and
+// points of the current model. A texture coordinate or normal stream
+// is only exported if every triangle vertex references it, or every
+// line and point vertex for models without triangles, otherwise the
+// respective field is left empty unless normals are generated as
+// requested by opts. Line and point vertices of a model with
+// triangles are zeroed where they lack an exported attribute.
+// Colours are exported if every referenced position has one.
+func (l *loader) exportModel() (model Model) {
+	model.Name = l.currentModel
+
+	for i, mtl := range l.faceMtls {
+		if n := len(model.Submeshes); n == 0 || model.Submeshes[n-1].MaterialName != mtl {
+			model.Submeshes = append(model.Submeshes, Submesh{
+				MaterialName: mtl,
+				IndexOffset:  uint32(i * 3),
+			})
+		}
+		model.Submeshes[len(model.Submeshes)-1].IndexCount += 3
+	}
 
-			currentMtl.SpecularTexture = split[1]
+	faces, normals := l.faces, l.normals
 
-		case "map_Bump", "map_bump": // normal_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_Bump at line %d", lineNumber)
+	hasTexCoords, hasNormals := true, true
+	hasColors := len(l.colors) != 0
+	check := func(idx [3]int32) {
+		hasTexCoords = hasTexCoords && idx[1] != 0
+		hasNormals = hasNormals && idx[2] != 0
+	}
+	for _, face := range faces {
+		for _, idx := range face {
+			check(idx)
+			hasColors = hasColors && l.colored[idx[0]-1]
+		}
+	}
+	for _, line := range l.lines {
+		for _, idx := range line {
+			if len(faces) == 0 {
+				check(idx)
 			}
+			hasColors = hasColors && l.colored[idx[0]-1]
+		}
+	}
+	for _, idx := range l.points {
+		if len(faces) == 0 {
+			check(idx)
+		}
+		hasColors = hasColors && l.colored[idx[0]-1]
+	}
 
-			currentMtl.NormalTexture = split[1]
+	generated := false
+	if !hasNormals && len(faces) != 0 && l.opts.GenerateNormals != NormalsNone {
+		normals, faces = generateNormals(l.vertices, faces, l.faceKeys, l.opts)
+		hasNormals, generated = true, true
+	}
 
-		case "map_Ns", "map_ns", "map_NS": // shininess_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_Ns at line %d", lineNumber)
-			}
+	l.cache.reset(len(l.vertices))
+	add := func(indices []uint32, idx [3]int32, triangle bool) []uint32 {
+		if !hasTexCoords {
+			idx[1] = 0
+		}
+		if !hasNormals || generated && !triangle {
+			// generated normals are only indexed by the triangles
+			idx[2] = 0
+		}
 
-			currentMtl.ShininessTexture = split[1]
+		if i, ok := l.cache.lookup(idx); ok {
+			return append(indices, i)
+		}
 
-		case "bump": // normal_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid bump at line %d", lineNumber)
+		model.Vertices = append(model.Vertices, l.vertices[idx[0]-1])
+		if hasTexCoords {
+			var texCoord [3]float32
+			if idx[1] != 0 {
+				texCoord = l.texCoords[idx[1]-1]
 			}
-
-			currentMtl.NormalTexture = split[1]
-
-		case "map_d": // dissolve_texture
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid map_d at line %d", lineNumber)
+			model.TextureCoords = append(model.TextureCoords, texCoord)
+		}
+		if hasNormals {
+			var normal [3]float32
+			if idx[2] != 0 {
+				normal = normals[idx[2]-1]
 			}
+			model.Normals = append(model.Normals, normal)
+		}
+		if hasColors {
+			model.Colors = append(model.Colors, l.colors[idx[0]-1])
+		}
+		return append(indices, l.cache.insert(idx))
+	}
 
-			currentMtl.DissolveTexture = split[1]
-
-		case "illum": // illumination_model
-			if len(split) < 2 {
-				return nil, fmt.Errorf("invalid illum at line %d", lineNumber)
-			}
+	model.Indices = make([]uint32, 0, len(faces)*3)
+	for _, face := range faces {
+		for _, idx := range face {
+			model.Indices = add(model.Indices, idx, true)
+		}
+	}
+	for _, line := range l.lines {
+		for _, idx := range line {
+			model.LineIndices = add(model.LineIndices, idx, false)
+		}
+	}
+	for _, idx := range l.points {
+		model.PointIndices = add(model.PointIndices, idx, false)
+	}
+	return
+}
 
-			x, err := strconv.ParseUint(split[1], 10, 8)
-			if err != nil {
-				return nil, fmt.Errorf("invalid illum at line %d", lineNumber)
-			}
+// vertexCache deduplicates face vertices. Instead of hashing, the
+// exported vertices are chained per position, a position is rarely
+// referenced with more than a few different attribute combinations.
+type vertexCache struct {
+	// heads[p] is the last exported vertex using position p, plus one
+	heads []uint32
+	// next[v] is the previous exported vertex with the same position
+	// as v, plus one
+	next []uint32
+	keys [][3]int32
+}
 
-			currentMtl.IlluminationModel = uint8(x)
+func (c *vertexCache) reset(numPositions int) {
+	for _, key := range c.keys {
+		c.heads[key[0]-1] = 0
+	}
+	if len(c.heads) < numPositions {
+		c.heads = append(c.heads, make([]uint32, numPositions-len(c.heads))...)
+	}
+	c.next = c.next[:0]
+	c.keys = c.keys[:0]
+}
 
-		case "#", "": // comment (ignored)
-		default: // unknown
+func (c *vertexCache) lookup(idx [3]int32) (uint32, bool) {
+	for v := c.heads[idx[0]-1]; v != 0; v = c.next[v-1] {
+		if c.keys[v-1] == idx {
+			return v - 1, true
 		}
 	}
+	return 0, false
+}
+
+func (c *vertexCache) insert(idx [3]int32) uint32 {
+	v := uint32(len(c.keys))
+	c.keys = append(c.keys, idx)
+	c.next = append(c.next, c.heads[idx[0]-1])
+	c.heads[idx[0]-1] = v + 1
+	return v
+}
 
-	materials = append(materials, currentMtl)
+// HasTextureCoords reports whether the model has a texture
+// coordinate for every vertex.
+func (m *Model) HasTextureCoords() bool {
+	return len(m.Vertices) != 0 && len(m.TextureCoords) == len(m.Vertices)
+}
+
+// HasNormals reports whether the model has a normal for every vertex.
+func (m *Model) HasNormals() bool {
+	return len(m.Vertices) != 0 && len(m.Normals) == len(m.Vertices)
+}
+
+// HasColors reports whether the model has a colour for every vertex.
+func (m *Model) HasColors() bool {
+	return len(m.Vertices) != 0 && len(m.Colors) == len(m.Vertices)
+}
 
-	return materials, nil
+// HasSkin reports whether the model has joints and weights for every
+// vertex.
+func (m *Model) HasSkin() bool {
+	return len(m.Vertices) != 0 && len(m.Joints) == len(m.Vertices) && len(m.Weights) == len(m.Vertices)
 }
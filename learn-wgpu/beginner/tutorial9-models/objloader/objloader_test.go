@@ -0,0 +1,123 @@
+package objloader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// benchGridSize is the number of quads along each side of the grid
+// parsed by BenchmarkLoadObjReader, 2*1500*1500 = 4.5M triangles.
+const benchGridSize = 1500
+
+var (
+	benchGridOnce sync.Once
+	benchGrid     []byte
+)
+
+// generateGrid returns an obj file of a size*size grid of textured
+// quads with a shared normal.
+func generateGrid(size int) []byte {
+	var b bytes.Buffer
+	b.WriteString("o grid\nvn 0 1 0\n")
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			u, v := float64(x)/float64(size), float64(z)/float64(size)
+			fmt.Fprintf(&b, "v %s 0 %s\nvt %s %s\n",
+				strconv.FormatFloat(u*100, 'f', 4, 32), strconv.FormatFloat(v*100, 'f', 4, 32),
+				strconv.FormatFloat(u, 'f', 6, 32), strconv.FormatFloat(v, 'f', 6, 32))
+		}
+	}
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			i := z*(size+1) + x + 1
+			fmt.Fprintf(&b, "f %d/%d/1 %d/%d/1 %d/%d/1 %d/%d/1\n",
+				i, i, i+size+1, i+size+1, i+size+2, i+size+2, i+1, i+1)
+		}
+	}
+	return b.Bytes()
+}
+
+func BenchmarkLoadObjReader(b *testing.B) {
+	benchGridOnce.Do(func() { benchGrid = generateGrid(benchGridSize) })
+
+	concurrency := []int{0, 4}
+	if n := runtime.GOMAXPROCS(0); n != 4 && n > 1 {
+		concurrency = append(concurrency, n)
+	}
+	for _, n := range concurrency {
+		name := "Sequential"
+		if n > 1 {
+			name = fmt.Sprintf("Concurrency=%d", n)
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(benchGrid)))
+			for i := 0; i < b.N; i++ {
+				if _, _, err := LoadObjReader(bytes.NewReader(benchGrid), nil, &LoadOptions{Concurrency: n}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// FuzzLoadObjReader checks that malformed input comes back as an error
+// instead of a panic, in strict and lenient mode and with sequential
+// and concurrent parsing.
+func FuzzLoadObjReader(f *testing.F) {
+	cube, err := os.ReadFile("../res/cube.obj")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(cube)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, lenient := range []bool{false, true} {
+			for _, concurrency := range []int{0, 4} {
+				LoadObjReader(bytes.NewReader(data), nil, &LoadOptions{
+					GenerateNormals: NormalsSmooth,
+					Concurrency:     concurrency,
+					Lenient:         lenient,
+				})
+			}
+		}
+	})
+}
+
+func TestLoadObjReaderSmoothing(t *testing.T) {
+	// a quad folded along its diagonal, so flat faces don't share
+	// normals
+	const quad = "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 1\n"
+	for _, tt := range []struct {
+		name, data string
+		mode       NormalMode
+		vertices   int
+	}{
+		// faces before the first s statement are smoothed
+		{"no s statement", quad + "f 1 2 3\nf 1 3 4\n", NormalsSmooth, 4},
+		{"s 1", quad + "s 1\nf 1 2 3\nf 1 3 4\n", NormalsSmooth, 4},
+		{"s off", quad + "s off\nf 1 2 3\nf 1 3 4\n", NormalsSmooth, 6},
+		{"s statement after the first face", quad + "f 1 2 3\ns 1\nf 1 3 4\n", NormalsSmooth, 6},
+		{"flat", quad + "f 1 2 3\nf 1 3 4\n", NormalsFlat, 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, concurrency := range []int{0, 4} {
+				models, _, err := LoadObjReader(bytes.NewReader([]byte(tt.data)), nil, &LoadOptions{
+					GenerateNormals: tt.mode,
+					Concurrency:     concurrency,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n := len(models[0].Vertices); n != tt.vertices {
+					t.Errorf("concurrency %d: %d vertices, want %d", concurrency, n, tt.vertices)
+				}
+			}
+		})
+	}
+}
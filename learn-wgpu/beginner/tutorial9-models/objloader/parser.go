@@ -0,0 +1,286 @@
+package objloader
+
+import (
+	"bytes"
+	"io"
+	"math"
+)
+
+// chunkSize is the amount of input parsed at once, rounded down to
+// the last complete line.
+const chunkSize = 1 << 20
+
+type elementKind uint8
+
+const (
+	elementObject elementKind = iota
+	elementMaterial
+	elementMtllib
+	elementSmoothing
+	elementGroup
+)
+
+// element is a statement other than vertex data or a face.
+type element struct {
+	kind   elementKind
+	line   int
+	column int
+	// faces is the number of faces in the chunk before the element
+	faces int
+
+	name      string
+	smoothing uint32
+}
+
+// face is a polygon, polyline or point list of refCount references
+// taken from chunk.refs. keyword is the first byte of the statement,
+// 'f', 'l' or 'p'.
+type face struct {
+	line     int
+	keyword  byte
+	refCount int32
+	// counts is the number of v, vt and vn read in the chunk before
+	// the face, needed to resolve relative indices
+	counts [3]int32
+}
+
+// chunk is the result of parsing a part of an obj file on its own.
+// Face references are kept as written, with 0 marking an absent
+// attribute, and are only resolved once merged.
+type chunk struct {
+	vertices  [][3]float32
+	texCoords [][3]float32
+	normals   [][3]float32
+	// colors is either empty or has an entry for every vertex, with
+	// colored telling whether the vertex had a colour
+	colors   [][3]float32
+	colored  []bool
+	refs     [][3]int32
+	faces    []face
+	elements []element
+	// err is the error the chunk stopped parsing at, if any
+	err      *ParseError
+	warnings []*ParseError
+}
+
+// readChunks reads r in chunks of complete lines and calls fn with
+// each of them and the line number it starts at, until fn returns
+// false. If fresh is set every chunk gets its own buffer, otherwise
+// the buffer is reused once fn returns.
+func readChunks(r io.Reader, fresh bool, fn func(data []byte, firstLine int) bool) error {
+	buf := make([]byte, chunkSize)
+	filled, line := 0, 1
+
+	for {
+		n, err := io.ReadFull(r, buf[filled:])
+		filled += n
+
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return err
+		}
+
+		end := filled
+		if !eof {
+			end = bytes.LastIndexByte(buf[:filled], '\n') + 1
+			if end == 0 {
+				// a single line longer than the buffer
+				buf = append(buf, make([]byte, len(buf))...)
+				continue
+			}
+		}
+
+		if end != 0 {
+			if !fn(buf[:end], line) {
+				return nil
+			}
+			line += bytes.Count(buf[:end], []byte{'\n'})
+		}
+		if eof {
+			return nil
+		}
+
+		next := buf
+		if fresh {
+			next = make([]byte, len(buf))
+		}
+		filled = copy(next, buf[end:filled])
+		buf = next
+	}
+}
+
+// parseChunk parses data, which must consist of complete lines, the
+// first of which is firstLine. In lenient mode malformed lines are
+// skipped and recorded as warnings.
+func parseChunk(data []byte, firstLine int, lenient bool) *chunk {
+	c := &chunk{}
+
+	for line := firstLine; len(data) != 0; line++ {
+		l := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			l, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+
+		if err := c.parseLine(l, line); err != nil {
+			if lenient {
+				c.warnings = append(c.warnings, err)
+				continue
+			}
+			c.err = err
+			break
+		}
+	}
+
+	return c
+}
+
+func (c *chunk) parseLine(l []byte, line int) *ParseError {
+	t := tokenizer{line: l}
+
+	keyword := t.next()
+	fail := func(err error) *ParseError {
+		return &ParseError{Line: line, Column: t.col, Directive: string(keyword), Err: err}
+	}
+
+	switch string(keyword) {
+	case "v", "vn", "vt":
+		// x y z, optionally followed by w or an r g b vertex colour
+		var v [7]float32
+		n, err := t.float32s(v[:])
+		if err != nil {
+			return fail(err)
+		}
+
+		switch string(keyword) {
+		case "v":
+			switch {
+			case n < 3:
+				return fail(ErrMissingValue)
+			case n == 6:
+				c.addColor(len(c.vertices), [3]float32{v[3], v[4], v[5]}, true)
+			case n == 7:
+				c.addColor(len(c.vertices), [3]float32{v[4], v[5], v[6]}, true)
+			case len(c.colors) != 0:
+				c.addColor(len(c.vertices), [3]float32{}, false)
+			}
+			c.vertices = append(c.vertices, [3]float32{v[0], v[1], v[2]})
+		case "vn":
+			if n < 3 {
+				return fail(ErrMissingValue)
+			}
+			c.normals = append(c.normals, [3]float32{v[0], v[1], v[2]})
+		case "vt":
+			if n < 1 {
+				return fail(ErrMissingValue)
+			}
+			c.texCoords = append(c.texCoords, [3]float32{v[0], v[1], v[2]})
+		}
+
+	case "f", "l", "p":
+		start := len(c.refs)
+		for field := t.next(); field != nil; field = t.next() {
+			ref, err := parseFaceRef(field)
+			if err != nil {
+				c.refs = c.refs[:start]
+				return fail(err)
+			}
+			c.refs = append(c.refs, ref)
+		}
+
+		refCount := len(c.refs) - start
+		if refCount == 0 || keyword[0] == 'l' && refCount == 1 {
+			c.refs = c.refs[:start]
+			return fail(ErrMissingValue)
+		}
+
+		c.faces = append(c.faces, face{
+			line:     line,
+			keyword:  keyword[0],
+			refCount: int32(refCount),
+			counts:   [3]int32{int32(len(c.vertices)), int32(len(c.texCoords)), int32(len(c.normals))},
+		})
+
+	case "s":
+		field := t.next()
+		if field == nil {
+			return fail(ErrMissingValue)
+		}
+
+		var smoothing int64
+		if string(field) != "off" {
+			var err error
+			smoothing, err = parseIntBytes(field)
+			if err != nil || smoothing < 0 || smoothing > 1<<32-1 {
+				return fail(ErrSyntax)
+			}
+		}
+		c.elements = append(c.elements, element{kind: elementSmoothing, line: line, faces: len(c.faces), smoothing: uint32(smoothing)})
+
+	case "g":
+		c.elements = append(c.elements, element{kind: elementGroup, line: line, faces: len(c.faces), name: string(t.rest())})
+
+	case "o", "mtllib", "usemtl":
+		name := t.next()
+		if name == nil {
+			return fail(ErrMissingValue)
+		}
+
+		kind := elementObject
+		switch keyword[0] {
+		case 'm':
+			kind = elementMtllib
+		case 'u':
+			kind = elementMaterial
+		}
+		c.elements = append(c.elements, element{kind: kind, line: line, column: t.col, faces: len(c.faces), name: string(name)})
+	}
+
+	return nil
+}
+
+// addColor sets the colour of vertex v, padding colors with
+// uncoloured entries for the vertices before it.
+func (c *chunk) addColor(v int, color [3]float32, ok bool) {
+	for len(c.colors) < v {
+		c.colors = append(c.colors, [3]float32{})
+		c.colored = append(c.colored, false)
+	}
+	c.colors = append(c.colors, color)
+	c.colored = append(c.colored, ok)
+}
+
+// parseFaceRef parses a face vertex reference in any of the "v",
+// "v/vt", "v//vn" or "v/vt/vn" forms, with 0 marking an absent
+// attribute.
+func parseFaceRef(field []byte) (ref [3]int32, err error) {
+	for i := 0; i < 3 && field != nil; i++ {
+		part := field
+		if j := bytes.IndexByte(field, '/'); j >= 0 {
+			part, field = field[:j], field[j+1:]
+		} else {
+			field = nil
+		}
+
+		if len(part) == 0 {
+			if i == 0 {
+				return ref, ErrMissingValue
+			}
+			continue
+		}
+
+		n, err := parseIntBytes(part)
+		if err != nil {
+			return ref, err
+		}
+		if n == 0 || n < math.MinInt32 || n > math.MaxInt32 {
+			return ref, ErrIndexOutOfRange
+		}
+		ref[i] = int32(n)
+	}
+	if field != nil {
+		return ref, ErrSyntax
+	}
+	return ref, nil
+}
@@ -0,0 +1,32 @@
+package objloader
+
+import "github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+
+// IntersectRay tests ray against every triangle of the model and
+// returns the distance to the nearest hit and the index of the hit
+// triangle, i.e. its vertices are Indices[triangle*3:triangle*3+3].
+func (m *Model) IntersectRay(ray glm.Ray[float32]) (t float32, triangle int, ok bool) {
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a := m.Vertices[m.Indices[i]]
+		b := m.Vertices[m.Indices[i+1]]
+		c := m.Vertices[m.Indices[i+2]]
+
+		hitT, _, _, hit := ray.IntersectTriangle(a, b, c)
+		if hit && (!ok || hitT < t) {
+			t, triangle, ok = hitT, i/3, true
+		}
+	}
+	return
+}
+
+// Pick returns the model and triangle nearest to the ray origin
+// among all models.
+func Pick(models []Model, ray glm.Ray[float32]) (model int, triangle int, t float32, ok bool) {
+	for i := range models {
+		hitT, tri, hit := models[i].IntersectRay(ray)
+		if hit && (!ok || hitT < t) {
+			model, triangle, t, ok = i, tri, hitT, true
+		}
+	}
+	return
+}
@@ -0,0 +1,206 @@
+package objloader
+
+import (
+	"math"
+	"strconv"
+)
+
+// tokenizer splits a line into whitespace separated fields without
+// allocating, the returned fields alias the line.
+type tokenizer struct {
+	line []byte
+	// pos is the number of bytes consumed so far and col the 1-based
+	// column of the last field returned
+	pos int
+	col int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// next returns the next field, or nil at the end of the line.
+func (t *tokenizer) next() []byte {
+	i := 0
+	for i < len(t.line) && isSpace(t.line[i]) {
+		i++
+	}
+	j := i
+	for j < len(t.line) && !isSpace(t.line[j]) {
+		j++
+	}
+
+	field := t.line[i:j]
+	t.line = t.line[j:]
+	t.col = t.pos + i + 1
+	t.pos += j
+	if len(field) == 0 {
+		return nil
+	}
+	return field
+}
+
+// rest returns the remainder of the line with surrounding whitespace
+// trimmed.
+func (t *tokenizer) rest() []byte {
+	i, j := 0, len(t.line)
+	for i < j && isSpace(t.line[i]) {
+		i++
+	}
+	for j > i && isSpace(t.line[j-1]) {
+		j--
+	}
+
+	rest := t.line[i:j]
+	t.col = t.pos + i + 1
+	t.pos += len(t.line)
+	t.line = nil
+	return rest
+}
+
+// float32s fills dst with the next len(dst) fields and returns how
+// many were present.
+func (t *tokenizer) float32s(dst []float32) (int, error) {
+	for i := range dst {
+		field := t.next()
+		if field == nil {
+			return i, nil
+		}
+
+		f, err := parseFloatBytes(field)
+		if err != nil {
+			return i, err
+		}
+		dst[i] = f
+	}
+	return len(dst), nil
+}
+
+var float64pow10 = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+	1e20, 1e21, 1e22,
+}
+
+// parseFloatBytes parses a decimal float. Inputs whose mantissa and
+// exponent are small enough to be computed exactly in float64 are
+// handled inline, everything else falls back to strconv.
+func parseFloatBytes(b []byte) (float32, error) {
+	i := 0
+	neg := false
+	if i < len(b) && (b[i] == '+' || b[i] == '-') {
+		neg = b[i] == '-'
+		i++
+	}
+
+	var (
+		mantissa uint64
+		digits   int
+		exp      int
+		sawDigit bool
+	)
+	for ; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+		sawDigit = true
+		if mantissa == 0 && b[i] == '0' {
+			continue
+		}
+		mantissa = mantissa*10 + uint64(b[i]-'0')
+		digits++
+	}
+	if i < len(b) && b[i] == '.' {
+		i++
+		for ; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+			sawDigit = true
+			exp--
+			if mantissa == 0 && b[i] == '0' {
+				continue
+			}
+			mantissa = mantissa*10 + uint64(b[i]-'0')
+			digits++
+		}
+	}
+	if !sawDigit {
+		return parseFloatSlow(b)
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		expNeg := false
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			expNeg = b[i] == '-'
+			i++
+		}
+		if i == len(b) {
+			return 0, ErrSyntax
+		}
+		e := 0
+		for ; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+			if e < 10000 {
+				e = e*10 + int(b[i]-'0')
+			}
+		}
+		if expNeg {
+			e = -e
+		}
+		exp += e
+	}
+	if i != len(b) {
+		return parseFloatSlow(b)
+	}
+
+	// 15 digits always fit the 53 bit float64 mantissa exactly
+	if digits > 15 || exp < -22 || exp > 22 {
+		return parseFloatSlow(b)
+	}
+
+	f := float64(mantissa)
+	if exp < 0 {
+		f /= float64pow10[-exp]
+	} else {
+		f *= float64pow10[exp]
+	}
+	if neg {
+		f = -f
+	}
+	return float32(f), nil
+}
+
+func parseFloatSlow(b []byte) (float32, error) {
+	f, err := strconv.ParseFloat(string(b), 32)
+	if err != nil {
+		return 0, ErrSyntax
+	}
+	return float32(f), nil
+}
+
+// parseIntBytes parses a signed decimal integer.
+func parseIntBytes(b []byte) (int64, error) {
+	if len(b) == 0 {
+		return 0, ErrSyntax
+	}
+
+	i := 0
+	neg := false
+	if b[0] == '+' || b[0] == '-' {
+		neg = b[0] == '-'
+		i++
+		if i == len(b) {
+			return 0, ErrSyntax
+		}
+	}
+
+	var n int64
+	for ; i < len(b); i++ {
+		c := b[i]
+		if c < '0' || c > '9' {
+			return 0, ErrSyntax
+		}
+		if n > (math.MaxInt64-9)/10 {
+			return 0, ErrSyntax
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
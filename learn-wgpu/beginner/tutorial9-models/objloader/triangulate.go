@@ -0,0 +1,157 @@
+package objloader
+
+import "math"
+
+// triangulate appends the triangles of a polygon to dst, as indices
+// into poly with the polygon's winding order preserved. Convex
+// polygons are fanned, concave ones are ear clipped after being
+// projected onto the plane they mostly lie in. pts is scratch space
+// for the projection, it is returned along with dst so both can be
+// reused and convex polygons are triangulated without allocating.
+func triangulate(dst [][3]int, pts [][2]float64, poly [][3]float32) ([][3]int, [][2]float64) {
+	n := len(poly)
+	if n < 3 {
+		return dst, pts
+	}
+	if n == 3 {
+		return append(dst, [3]int{0, 1, 2}), pts
+	}
+
+	pts = project(pts[:0], poly)
+	area := signedArea(pts)
+
+	if isConvex(pts, area) {
+		for i := 1; i < n-1; i++ {
+			dst = append(dst, [3]int{0, i, i + 1})
+		}
+		return dst, pts
+	}
+
+	return earClip(dst, pts, area), pts
+}
+
+// project drops the axis along which the polygon's Newell normal is
+// largest, flipping the remaining axes so that a counter-clockwise
+// polygon around the normal stays counter-clockwise in 2D. The points
+// are appended to pts.
+func project(pts [][2]float64, poly [][3]float32) [][2]float64 {
+	var nx, ny, nz float64
+	for i := range poly {
+		c := poly[i]
+		nxt := poly[(i+1)%len(poly)]
+		nx += float64(c[1]-nxt[1]) * float64(c[2]+nxt[2])
+		ny += float64(c[2]-nxt[2]) * float64(c[0]+nxt[0])
+		nz += float64(c[0]-nxt[0]) * float64(c[1]+nxt[1])
+	}
+
+	ax, ay, az := math.Abs(nx), math.Abs(ny), math.Abs(nz)
+
+	for _, p := range poly {
+		var pt [2]float64
+		switch {
+		case az >= ax && az >= ay:
+			pt = [2]float64{float64(p[0]), float64(p[1])}
+			if nz < 0 {
+				pt[0] = -pt[0]
+			}
+		case ax >= ay:
+			pt = [2]float64{float64(p[1]), float64(p[2])}
+			if nx < 0 {
+				pt[0] = -pt[0]
+			}
+		default:
+			pt = [2]float64{float64(p[2]), float64(p[0])}
+			if ny < 0 {
+				pt[0] = -pt[0]
+			}
+		}
+		pts = append(pts, pt)
+	}
+	return pts
+}
+
+func signedArea(pts [][2]float64) float64 {
+	var a float64
+	for i := range pts {
+		c := pts[i]
+		nxt := pts[(i+1)%len(pts)]
+		a += c[0]*nxt[1] - nxt[0]*c[1]
+	}
+	return a / 2
+}
+
+func cross2(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func isConvex(pts [][2]float64, area float64) bool {
+	n := len(pts)
+	for i := 0; i < n; i++ {
+		if cross2(pts[i], pts[(i+1)%n], pts[(i+2)%n])*area < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func pointInTriangle(p, a, b, c [2]float64) bool {
+	d1 := cross2(a, b, p)
+	d2 := cross2(b, c, p)
+	d3 := cross2(c, a, p)
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+func earClip(tris [][3]int, pts [][2]float64, area float64) [][3]int {
+	n := len(pts)
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	for len(remaining) > 3 {
+		m := len(remaining)
+		clipped := false
+
+		for i := 0; i < m; i++ {
+			prev := remaining[(i+m-1)%m]
+			cur := remaining[i]
+			next := remaining[(i+1)%m]
+
+			// reflex or degenerate corner
+			if cross2(pts[prev], pts[cur], pts[next])*area <= 0 {
+				continue
+			}
+
+			ear := true
+			for _, j := range remaining {
+				if j == prev || j == cur || j == next {
+					continue
+				}
+				if pointInTriangle(pts[j], pts[prev], pts[cur], pts[next]) {
+					ear = false
+					break
+				}
+			}
+			if !ear {
+				continue
+			}
+
+			tris = append(tris, [3]int{prev, cur, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+
+		// self-intersecting or degenerate input, fan the rest
+		if !clipped {
+			for i := 1; i < len(remaining)-1; i++ {
+				tris = append(tris, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return tris
+		}
+	}
+
+	return append(tris, [3]int{remaining[0], remaining[1], remaining[2]})
+}
@@ -0,0 +1,374 @@
+package objloader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+// WriteOptions controls how WriteObj and WriteMtl serialise models
+// and materials.
+type WriteOptions struct {
+	// Precision is the number of digits written after the decimal
+	// point, values <= 0 write the shortest representation that reads
+	// back to the same float32.
+	Precision int
+	// Weld writes every distinct position, texture coordinate and
+	// normal only once, merging identical vertices across models.
+	Weld bool
+	// MtlLib, if set, is referenced by a mtllib statement.
+	MtlLib string
+	// TextureDir, if set, is the directory texture paths are written
+	// relative to, usually the directory of the mtl file.
+	TextureDir string
+}
+
+type writer struct {
+	w    *bufio.Writer
+	buf  []byte
+	opts *WriteOptions
+}
+
+func newWriter(w io.Writer, opts *WriteOptions) *writer {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+	return &writer{w: bufio.NewWriter(w), opts: opts}
+}
+
+func (w *writer) keyword(k string) {
+	w.buf = append(w.buf[:0], k...)
+}
+
+func (w *writer) float(f float32) {
+	w.buf = append(w.buf, ' ')
+	if w.opts.Precision > 0 {
+		w.buf = strconv.AppendFloat(w.buf, float64(f), 'f', w.opts.Precision, 32)
+	} else {
+		w.buf = strconv.AppendFloat(w.buf, float64(f), 'g', -1, 32)
+	}
+}
+
+func (w *writer) floats(fs ...float32) {
+	for _, f := range fs {
+		w.float(f)
+	}
+}
+
+func (w *writer) int(i int) {
+	w.buf = append(w.buf, ' ')
+	w.buf = strconv.AppendInt(w.buf, int64(i), 10)
+}
+
+func (w *writer) string(s string) {
+	w.buf = append(w.buf, ' ')
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) endLine() {
+	w.buf = append(w.buf, '\n')
+	w.w.Write(w.buf)
+}
+
+// WriteObj writes models as obj data to w. opts may be nil, in which
+// case the defaults are used.
+func WriteObj(w io.Writer, models []Model, opts *WriteOptions) error {
+	for _, m := range models {
+		for _, indices := range [][]uint32{m.Indices, m.LineIndices, m.PointIndices} {
+			for _, idx := range indices {
+				if int(idx) >= len(m.Vertices) {
+					return fmt.Errorf("model %q: index %d out of range", m.Name, idx)
+				}
+			}
+		}
+		for _, sm := range m.Submeshes {
+			if uint64(sm.IndexOffset)+uint64(sm.IndexCount) > uint64(len(m.Indices)) {
+				return fmt.Errorf("model %q: submesh out of range", m.Name)
+			}
+		}
+	}
+
+	ow := newWriter(w, opts)
+
+	if ow.opts.MtlLib != "" {
+		ow.keyword("mtllib")
+		ow.string(ow.opts.MtlLib)
+		ow.endLine()
+	}
+
+	var (
+		positions = stream{weld: ow.opts.Weld}
+		texCoords = stream{weld: ow.opts.Weld}
+		normals   = stream{weld: ow.opts.Weld}
+		refs      = make([][][3]int, len(models))
+	)
+
+	// vertex data is written up front so that faces can use absolute
+	// indices even when welding across models
+	for i, m := range models {
+		refs[i] = make([][3]int, len(m.Vertices))
+		for v := range m.Vertices {
+			position := m.Vertices[v][:]
+			if m.HasColors() {
+				c := m.Colors[v]
+				if m.LinearColors {
+					c = glm.Color[float32]{R: c[0], G: c[1], B: c[2]}.ToSRGB().Vec3()
+				}
+				position = append(position, c[:]...)
+			}
+			refs[i][v][0] = positions.add(ow, "v", position...)
+			if m.HasTextureCoords() {
+				refs[i][v][1] = texCoords.add(ow, "vt", m.TextureCoords[v][:]...)
+			}
+			if m.HasNormals() {
+				refs[i][v][2] = normals.add(ow, "vn", m.Normals[v][:]...)
+			}
+		}
+	}
+
+	for i, m := range models {
+		name := m.Name
+		if name == "" {
+			name = "unnamed_object"
+		}
+		ow.keyword("o")
+		ow.string(name)
+		ow.endLine()
+
+		submeshes := m.Submeshes
+		if len(submeshes) == 0 {
+			submeshes = []Submesh{{IndexCount: uint32(len(m.Indices))}}
+		}
+
+		for _, sm := range submeshes {
+			if sm.MaterialName != "" {
+				ow.keyword("usemtl")
+				ow.string(sm.MaterialName)
+				ow.endLine()
+			}
+
+			indices := m.Indices[sm.IndexOffset : sm.IndexOffset+sm.IndexCount]
+			for f := 0; f+2 < len(indices); f += 3 {
+				ow.keyword("f")
+				for _, idx := range indices[f : f+3] {
+					ow.ref(refs[i][idx])
+				}
+				ow.endLine()
+			}
+		}
+
+		// l statements can't reference normals and p statements only
+		// reference positions
+		for l := 0; l+1 < len(m.LineIndices); l += 2 {
+			ow.keyword("l")
+			for _, idx := range m.LineIndices[l : l+2] {
+				ow.ref([3]int{refs[i][idx][0], refs[i][idx][1]})
+			}
+			ow.endLine()
+		}
+		for _, idx := range m.PointIndices {
+			ow.keyword("p")
+			ow.int(refs[i][idx][0])
+			ow.endLine()
+		}
+	}
+
+	return ow.w.Flush()
+}
+
+// ref writes a face vertex reference in the shortest form for the
+// attributes it has.
+func (w *writer) ref(ref [3]int) {
+	w.int(ref[0])
+	switch {
+	case ref[1] != 0 && ref[2] != 0:
+		w.buf = append(w.buf, '/')
+		w.buf = strconv.AppendInt(w.buf, int64(ref[1]), 10)
+		w.buf = append(w.buf, '/')
+		w.buf = strconv.AppendInt(w.buf, int64(ref[2]), 10)
+	case ref[1] != 0:
+		w.buf = append(w.buf, '/')
+		w.buf = strconv.AppendInt(w.buf, int64(ref[1]), 10)
+	case ref[2] != 0:
+		w.buf = append(w.buf, "//"...)
+		w.buf = strconv.AppendInt(w.buf, int64(ref[2]), 10)
+	}
+}
+
+// stream writes the elements of one vertex attribute and hands out
+// their 1-based indices.
+type stream struct {
+	weld  bool
+	count int
+	seen  map[streamKey]int
+}
+
+// streamKey is the values of an element, up to a position and its
+// colour.
+type streamKey struct {
+	values [6]float32
+	n      int
+}
+
+func (s *stream) add(w *writer, keyword string, v ...float32) int {
+	key := streamKey{n: len(v)}
+	copy(key.values[:], v)
+
+	if s.weld {
+		if i, ok := s.seen[key]; ok {
+			return i
+		}
+		if s.seen == nil {
+			s.seen = map[streamKey]int{}
+		}
+	}
+
+	s.count++
+	if s.weld {
+		s.seen[key] = s.count
+	}
+
+	w.keyword(keyword)
+	if keyword == "vt" && v[2] == 0 {
+		w.floats(v[0], v[1])
+	} else {
+		w.floats(v...)
+	}
+	w.endLine()
+
+	return s.count
+}
+
+// WriteMtl writes materials as mtl data to w. opts may be nil, in
+// which case the defaults are used.
+func WriteMtl(w io.Writer, materials []Material, opts *WriteOptions) error {
+	mw := newWriter(w, opts)
+
+	for i, m := range materials {
+		if i != 0 {
+			mw.w.WriteByte('\n')
+		}
+
+		mw.keyword("newmtl")
+		mw.string(m.Name)
+		mw.endLine()
+
+		mw.color("Ka", m.Ambient, true)
+		mw.color("Kd", m.Diffuse, true)
+		mw.color("Ks", m.Specular, true)
+		mw.color("Ke", m.Emissive, false)
+
+		mw.scalar("Ns", m.Shininess, true)
+		mw.scalar("Ni", m.OpticalDensity, true)
+		mw.scalar("d", m.Dissolve, true)
+		mw.keyword("illum")
+		mw.int(int(m.IlluminationModel))
+		mw.endLine()
+
+		mw.scalar("Pr", m.Roughness, false)
+		mw.scalar("Pm", m.Metallic, false)
+		mw.scalar("Ps", m.Sheen, false)
+		mw.scalar("Pc", m.ClearcoatThickness, false)
+		mw.scalar("Pcr", m.ClearcoatRoughness, false)
+		mw.scalar("aniso", m.Anisotropy, false)
+		mw.scalar("anisor", m.AnisotropyRotation, false)
+
+		mw.texture("map_Ka", m.AmbientTexture)
+		mw.texture("map_Kd", m.DiffuseTexture)
+		mw.texture("map_Ks", m.SpecularTexture)
+		mw.texture("map_Ke", m.EmissiveTexture)
+		mw.texture("map_Bump", m.NormalTexture)
+		mw.texture("map_Ns", m.ShininessTexture)
+		mw.texture("map_d", m.DissolveTexture)
+		mw.texture("map_Pr", m.RoughnessTexture)
+		mw.texture("map_Pm", m.MetallicTexture)
+		mw.texture("map_Ps", m.SheenTexture)
+	}
+
+	return mw.w.Flush()
+}
+
+func (w *writer) color(keyword string, c [3]float32, always bool) {
+	if !always && c == [3]float32{} {
+		return
+	}
+	w.keyword(keyword)
+	w.floats(c[:]...)
+	w.endLine()
+}
+
+func (w *writer) scalar(keyword string, f float32, always bool) {
+	if !always && f == 0 {
+		return
+	}
+	w.keyword(keyword)
+	w.float(f)
+	w.endLine()
+}
+
+// texture writes a texture map statement with the options that differ
+// from their defaults, zero values included.
+func (w *writer) texture(keyword string, ref TextureRef) {
+	if ref.Path == "" {
+		return
+	}
+
+	w.keyword(keyword)
+	if ref.Scale != [3]float32{} && ref.Scale != [3]float32{1, 1, 1} {
+		w.string("-s")
+		w.floats(ref.Scale[:]...)
+	}
+	if ref.Offset != [3]float32{} {
+		w.string("-o")
+		w.floats(ref.Offset[:]...)
+	}
+	if ref.Turbulence != [3]float32{} {
+		w.string("-t")
+		w.floats(ref.Turbulence[:]...)
+	}
+	if ref.BumpMultiplier != 0 && ref.BumpMultiplier != 1 {
+		w.string("-bm")
+		w.float(ref.BumpMultiplier)
+	}
+	if ref.Clamp {
+		w.string("-clamp on")
+	}
+	if ref.NoBlendU {
+		w.string("-blendu off")
+	}
+	if ref.NoBlendV {
+		w.string("-blendv off")
+	}
+	if ref.Boost != 0 {
+		w.string("-boost")
+		w.float(ref.Boost)
+	}
+	if gain := ref.Gain; ref.Base != 0 || (gain != 0 && gain != 1) {
+		if gain == 0 {
+			gain = 1
+		}
+		w.string("-mm")
+		w.floats(ref.Base, gain)
+	}
+	if ref.Channel != "" {
+		w.string("-imfchan")
+		w.string(ref.Channel)
+	}
+	if ref.Resolution != 0 {
+		w.string("-texres")
+		w.int(ref.Resolution)
+	}
+
+	path := ref.Path
+	if w.opts.TextureDir != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(w.opts.TextureDir, path); err == nil {
+			path = rel
+		}
+	}
+	w.string(filepath.ToSlash(path))
+	w.endLine()
+}
@@ -0,0 +1,213 @@
+package objloader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+// roundTripModels are written by WriteObj and loaded again. The model
+// without materials comes first, as a usemtl statement applies until
+// the next one.
+var roundTripModels = []Model{
+	{
+		Name:     "normals",
+		Vertices: [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Normals:  [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		Indices:  []uint32{0, 1, 2},
+	},
+	{
+		Name:          "textured",
+		Vertices:      [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0.5, 0.5, 0.1}},
+		TextureCoords: [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0.5, 0.5, 0.25}},
+		Normals:       [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0.6, 0.8}},
+		Colors:        [][3]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 1}, {0.25, 0.5, 0.75}},
+		Indices:       []uint32{0, 1, 2, 0, 2, 3, 0, 1, 4},
+		Submeshes: []Submesh{
+			{MaterialName: "red", IndexCount: 6},
+			{MaterialName: "blue", IndexOffset: 6, IndexCount: 3},
+		},
+		LineIndices:  []uint32{0, 2, 1, 3},
+		PointIndices: []uint32{4},
+	},
+	{
+		Name:         "wire",
+		Vertices:     [][3]float32{{0, 0, 0}, {0, 0, 1}, {0, 0, 2}},
+		LineIndices:  []uint32{0, 1, 1, 2},
+		PointIndices: []uint32{2, 0},
+	},
+}
+
+// checkVertex compares vertex b of got with vertex a of want. Line and
+// point vertices of models with triangles only keep their position,
+// texture coordinate and colour, as l statements can't reference
+// normals and p statements only reference positions.
+func checkVertex(t *testing.T, want, got *Model, a, b uint32, kind string, texCoords, normals bool) {
+	t.Helper()
+	if want.Vertices[a] != got.Vertices[b] {
+		t.Errorf("%s: %s vertex %d: position %v, want %v", want.Name, kind, a, got.Vertices[b], want.Vertices[a])
+	}
+	if want.HasColors() != got.HasColors() {
+		t.Errorf("%s: has colours %v, want %v", want.Name, got.HasColors(), want.HasColors())
+	} else if want.HasColors() && want.Colors[a] != got.Colors[b] {
+		t.Errorf("%s: %s vertex %d: colour %v, want %v", want.Name, kind, a, got.Colors[b], want.Colors[a])
+	}
+	if texCoords && want.TextureCoords[a] != got.TextureCoords[b] {
+		t.Errorf("%s: %s vertex %d: texture coordinate %v, want %v", want.Name, kind, a, got.TextureCoords[b], want.TextureCoords[a])
+	}
+	if normals && want.Normals[a] != got.Normals[b] {
+		t.Errorf("%s: %s vertex %d: normal %v, want %v", want.Name, kind, a, got.Normals[b], want.Normals[a])
+	}
+}
+
+func TestWriteObjRoundTrip(t *testing.T) {
+	for _, weld := range []bool{false, true} {
+		var b bytes.Buffer
+		if err := WriteObj(&b, roundTripModels, &WriteOptions{Weld: weld}); err != nil {
+			t.Fatal(err)
+		}
+		models, _, err := LoadObjReader(bytes.NewReader(b.Bytes()), nil, nil)
+		if err != nil {
+			t.Fatalf("weld %v: %v\n%s", weld, err, b.Bytes())
+		}
+		if len(models) != len(roundTripModels) {
+			t.Fatalf("weld %v: %d models, want %d", weld, len(models), len(roundTripModels))
+		}
+
+		for i := range models {
+			want, got := &roundTripModels[i], &models[i]
+			if got.Name != want.Name {
+				t.Errorf("weld %v: model %d is %q, want %q", weld, i, got.Name, want.Name)
+			}
+			if got.HasTextureCoords() != want.HasTextureCoords() || got.HasNormals() != want.HasNormals() {
+				t.Errorf("weld %v: %s: texture coordinates %v and normals %v, want %v and %v", weld, want.Name,
+					got.HasTextureCoords(), got.HasNormals(), want.HasTextureCoords(), want.HasNormals())
+				continue
+			}
+			if len(want.Submeshes) != 0 && !slices.Equal(got.Submeshes, want.Submeshes) {
+				t.Errorf("weld %v: %s: submeshes %v, want %v", weld, want.Name, got.Submeshes, want.Submeshes)
+			}
+
+			for _, stream := range []struct {
+				kind      string
+				want, got []uint32
+				normals   bool
+				texCoords bool
+			}{
+				{"triangle", want.Indices, got.Indices, want.HasNormals(), want.HasTextureCoords()},
+				{"line", want.LineIndices, got.LineIndices, len(want.Indices) == 0 && want.HasNormals(), want.HasTextureCoords()},
+				{"point", want.PointIndices, got.PointIndices, len(want.Indices) == 0 && want.HasNormals(), len(want.Indices) == 0 && want.HasTextureCoords()},
+			} {
+				if len(stream.got) != len(stream.want) {
+					t.Errorf("weld %v: %s: %d %s indices, want %d", weld, want.Name, len(stream.got), stream.kind, len(stream.want))
+					continue
+				}
+				for j := range stream.want {
+					checkVertex(t, want, got, stream.want[j], stream.got[j], stream.kind, stream.texCoords, stream.normals)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteObjWeld(t *testing.T) {
+	var plain, welded bytes.Buffer
+	if err := WriteObj(&plain, roundTripModels, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteObj(&welded, roundTripModels, &WriteOptions{Weld: true}); err != nil {
+		t.Fatal(err)
+	}
+	// the origin and the +z normal are shared by the models
+	if p, w := strings.Count(plain.String(), "\nv "), strings.Count(welded.String(), "\nv "); w >= p {
+		t.Errorf("welding wrote %d positions, %d without", w, p)
+	}
+	if p, w := strings.Count(plain.String(), "\nvn "), strings.Count(welded.String(), "\nvn "); w != 2 || w >= p {
+		t.Errorf("welding wrote %d normals, want 2 of %d", w, p)
+	}
+}
+
+func TestWriteMtlRoundTrip(t *testing.T) {
+	materials := []Material{
+		{
+			Name:           "plain",
+			Diffuse:        [3]float32{0.8, 0.2, 0.2},
+			Dissolve:       1,
+			DiffuseTexture: TextureRef{Path: "a b.png"},
+		},
+		{
+			Name:              "options",
+			Ambient:           [3]float32{0.1, 0.1, 0.1},
+			Specular:          [3]float32{0.5, 0.5, 0.5},
+			Emissive:          [3]float32{0, 0.25, 0},
+			Shininess:         96,
+			OpticalDensity:    1.45,
+			Dissolve:          0.5,
+			IlluminationModel: 2,
+			Roughness:         0.3,
+			Metallic:          1,
+			DiffuseTexture: TextureRef{
+				Path:       "textures/diffuse map.png",
+				Scale:      [3]float32{2, 3, 1},
+				Offset:     [3]float32{0.5, 0, 0},
+				Turbulence: [3]float32{0.1, 0.2, 0.3},
+				Clamp:      true,
+				NoBlendU:   true,
+				NoBlendV:   true,
+				Boost:      1.5,
+				Base:       0.25,
+				Gain:       0.5,
+				Resolution: 512,
+			},
+			NormalTexture:    TextureRef{Path: "normal.png", BumpMultiplier: 0.5},
+			RoughnessTexture: TextureRef{Path: "roughness.png", Channel: "r"},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := WriteMtl(&b, materials, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "\nmap_Kd a b.png\n") {
+		t.Errorf("a texture without options is written with them:\n%s", b.Bytes())
+	}
+
+	loaded, err := loadMtl(bytes.NewReader(b.Bytes()), "", &LoadOptions{})
+	if err != nil {
+		t.Fatalf("%v\n%s", err, b.Bytes())
+	}
+	if len(loaded) != len(materials) {
+		t.Fatalf("%d materials, want %d", len(loaded), len(materials))
+	}
+	for i := range materials {
+		if loaded[i] != materials[i] {
+			t.Errorf("material %d:\n got %+v\nwant %+v", i, loaded[i], materials[i])
+		}
+	}
+}
+
+func TestWriteObjLinearColors(t *testing.T) {
+	m := Model{
+		Vertices:     [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Colors:       [][3]float32{{0.21404114, 0, 1}, {0, 0, 0}, {1, 1, 1}},
+		LinearColors: true,
+		Indices:      []uint32{0, 1, 2},
+	}
+	var b bytes.Buffer
+	if err := WriteObj(&b, []Model{m}, nil); err != nil {
+		t.Fatal(err)
+	}
+	models, _, err := LoadObjReader(bytes.NewReader(b.Bytes()), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// obj colours are sRGB encoded
+	if c := models[0].Colors[0]; c[0] < 0.499 || c[0] > 0.501 || c[1] != 0 || c[2] != 1 {
+		t.Errorf("colour %v, want about 0.5 0 1", c)
+	}
+	if models[0].LinearColors {
+		t.Error("loaded colours are linear")
+	}
+}
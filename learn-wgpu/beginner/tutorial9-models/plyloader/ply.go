@@ -0,0 +1,526 @@
+// Package plyloader loads PLY polygon files, in the ASCII and both
+// binary formats, into objloader models.
+package plyloader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+type format uint8
+
+const (
+	formatASCII format = iota
+	formatBinaryLittleEndian
+	formatBinaryBigEndian
+)
+
+type scalarType uint8
+
+const (
+	typeInt8 scalarType = iota + 1
+	typeUint8
+	typeInt16
+	typeUint16
+	typeInt32
+	typeUint32
+	typeFloat32
+	typeFloat64
+)
+
+var scalarTypes = map[string]scalarType{
+	"char": typeInt8, "int8": typeInt8,
+	"uchar": typeUint8, "uint8": typeUint8,
+	"short": typeInt16, "int16": typeInt16,
+	"ushort": typeUint16, "uint16": typeUint16,
+	"int": typeInt32, "int32": typeInt32,
+	"uint": typeUint32, "uint32": typeUint32,
+	"float": typeFloat32, "float32": typeFloat32,
+	"double": typeFloat64, "float64": typeFloat64,
+}
+
+func (t scalarType) size() int {
+	switch t {
+	case typeInt8, typeUint8:
+		return 1
+	case typeInt16, typeUint16:
+		return 2
+	case typeInt32, typeUint32, typeFloat32:
+		return 4
+	}
+	return 8
+}
+
+// max returns the value integer colours of type t are normalized by.
+func (t scalarType) max() float64 {
+	switch t {
+	case typeInt8:
+		return math.MaxInt8
+	case typeUint8:
+		return math.MaxUint8
+	case typeInt16:
+		return math.MaxInt16
+	case typeUint16:
+		return math.MaxUint16
+	case typeInt32:
+		return math.MaxInt32
+	case typeUint32:
+		return math.MaxUint32
+	}
+	return 1
+}
+
+type property struct {
+	name string
+	typ  scalarType
+	// countType is the type of the length of list properties, 0 for
+	// scalar properties
+	countType scalarType
+}
+
+type element struct {
+	name       string
+	count      int
+	properties []property
+}
+
+// Load loads the PLY file name from dir.
+func Load(dir fs.FS, name string) (objloader.Model, error) {
+	f, err := dir.Open(name)
+	if err != nil {
+		return objloader.Model{}, err
+	}
+	defer f.Close()
+
+	m, err := LoadReader(f)
+	if err != nil {
+		return m, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+// LoadReader loads a PLY file from r. Vertex positions, normals,
+// colours and texture coordinates are read from the vertex element,
+// which needs x, y and z, polygons from the vertex_indices of the face
+// element, which are triangulated as fans, and lines from the edge
+// element. A file with neither faces nor edges is a point cloud, all
+// of its vertices are put in PointIndices. Other elements and
+// properties are skipped.
+func LoadReader(r io.Reader) (objloader.Model, error) {
+	br := bufio.NewReader(r)
+	format, elements, err := readHeader(br)
+	if err != nil {
+		return objloader.Model{}, err
+	}
+
+	var values valueReader
+	switch format {
+	case formatASCII:
+		scanner := bufio.NewScanner(br)
+		scanner.Split(bufio.ScanWords)
+		values = &asciiReader{scanner: scanner}
+	case formatBinaryLittleEndian:
+		values = &binaryReader{r: br, order: binary.LittleEndian}
+	case formatBinaryBigEndian:
+		values = &binaryReader{r: br, order: binary.BigEndian}
+	}
+
+	l := &loader{values: values}
+	for _, e := range elements {
+		if err := l.element(e); err != nil {
+			return objloader.Model{}, fmt.Errorf("ply: element %s: %w", e.name, err)
+		}
+	}
+
+	m := l.model
+	for _, indices := range [][]uint32{m.Indices, m.LineIndices} {
+		for _, index := range indices {
+			if int(index) >= len(m.Vertices) {
+				return objloader.Model{}, fmt.Errorf("ply: vertex index %d: %w", index, objloader.ErrIndexOutOfRange)
+			}
+		}
+	}
+	if !l.hasFaces && !l.hasEdges {
+		for i := range m.Vertices {
+			m.PointIndices = append(m.PointIndices, uint32(i))
+		}
+	}
+	if len(m.Indices) != 0 {
+		m.Submeshes = []objloader.Submesh{{IndexCount: uint32(len(m.Indices))}}
+	}
+	return m, nil
+}
+
+func readHeader(r *bufio.Reader) (format, []element, error) {
+	var (
+		f         format
+		hasFormat bool
+		elements  []element
+	)
+	for line := 1; ; line++ {
+		s, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = errors.New("missing end_header")
+			}
+			return f, nil, fmt.Errorf("ply: header: %w", err)
+		}
+		fields := strings.Fields(s)
+
+		if line == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return f, nil, errors.New("ply: not a PLY file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		invalid := fmt.Errorf("ply: header line %d: %w", line, objloader.ErrSyntax)
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return f, nil, invalid
+			}
+			switch fields[1] {
+			case "ascii":
+				f = formatASCII
+			case "binary_little_endian":
+				f = formatBinaryLittleEndian
+			case "binary_big_endian":
+				f = formatBinaryBigEndian
+			default:
+				return f, nil, fmt.Errorf("ply: format %s: %w", fields[1], objloader.ErrUnsupported)
+			}
+			hasFormat = true
+		case "element":
+			if len(fields) != 3 {
+				return f, nil, invalid
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return f, nil, invalid
+			}
+			elements = append(elements, element{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return f, nil, invalid
+			}
+			var p property
+			switch {
+			case len(fields) == 3:
+				p = property{name: fields[2], typ: scalarTypes[fields[1]]}
+			case len(fields) == 5 && fields[1] == "list":
+				p = property{name: fields[4], typ: scalarTypes[fields[3]], countType: scalarTypes[fields[2]]}
+				if p.countType == 0 || p.countType == typeFloat32 || p.countType == typeFloat64 {
+					return f, nil, invalid
+				}
+			default:
+				return f, nil, invalid
+			}
+			if p.typ == 0 {
+				return f, nil, invalid
+			}
+			e := &elements[len(elements)-1]
+			e.properties = append(e.properties, p)
+		case "comment", "obj_info":
+		case "end_header":
+			if !hasFormat {
+				return f, nil, errors.New("ply: missing format")
+			}
+			return f, elements, nil
+		default:
+			return f, nil, invalid
+		}
+	}
+}
+
+// valueReader reads the values of the body in order.
+type valueReader interface {
+	read(t scalarType) (float64, error)
+}
+
+type asciiReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *asciiReader) read(t scalarType) (float64, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	v, err := strconv.ParseFloat(r.scanner.Text(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q: %w", r.scanner.Text(), objloader.ErrSyntax)
+	}
+	return v, nil
+}
+
+type binaryReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (r *binaryReader) read(t scalarType) (float64, error) {
+	b := r.buf[:t.size()]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch t {
+	case typeInt8:
+		return float64(int8(b[0])), nil
+	case typeUint8:
+		return float64(b[0]), nil
+	case typeInt16:
+		return float64(int16(r.order.Uint16(b))), nil
+	case typeUint16:
+		return float64(r.order.Uint16(b)), nil
+	case typeInt32:
+		return float64(int32(r.order.Uint32(b))), nil
+	case typeUint32:
+		return float64(r.order.Uint32(b)), nil
+	case typeFloat32:
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	}
+	return math.Float64frombits(r.order.Uint64(b)), nil
+}
+
+type loader struct {
+	values   valueReader
+	model    objloader.Model
+	hasFaces bool
+	hasEdges bool
+}
+
+// attribute is where a vertex property is stored: the stream, by
+// index into loader.vertex, and the component.
+type attribute struct {
+	stream    int
+	component int
+}
+
+const (
+	streamPosition = iota
+	streamNormal
+	streamColor
+	streamTexCoord
+)
+
+var vertexAttributes = map[string]attribute{
+	"x": {streamPosition, 0}, "y": {streamPosition, 1}, "z": {streamPosition, 2},
+	"nx": {streamNormal, 0}, "ny": {streamNormal, 1}, "nz": {streamNormal, 2},
+	"red": {streamColor, 0}, "green": {streamColor, 1}, "blue": {streamColor, 2},
+	"r": {streamColor, 0}, "g": {streamColor, 1}, "b": {streamColor, 2},
+	"diffuse_red": {streamColor, 0}, "diffuse_green": {streamColor, 1}, "diffuse_blue": {streamColor, 2},
+	"u": {streamTexCoord, 0}, "v": {streamTexCoord, 1},
+	"s": {streamTexCoord, 0}, "t": {streamTexCoord, 1},
+	"texture_u": {streamTexCoord, 0}, "texture_v": {streamTexCoord, 1},
+	"texture_s": {streamTexCoord, 0}, "texture_t": {streamTexCoord, 1},
+}
+
+func (l *loader) element(e element) error {
+	switch e.name {
+	case "vertex":
+		return l.vertices(e)
+	case "face":
+		l.hasFaces = l.hasFaces || e.count > 0
+		polygon := []uint32{}
+		return l.lists(e, func(p property, list []float64) error {
+			if p.name != "vertex_indices" && p.name != "vertex_index" {
+				return nil
+			}
+			polygon = polygon[:0]
+			for _, v := range list {
+				i, err := index(v)
+				if err != nil {
+					return err
+				}
+				polygon = append(polygon, i)
+			}
+			for v := 2; v < len(polygon); v++ {
+				l.model.Indices = append(l.model.Indices, polygon[0], polygon[v-1], polygon[v])
+			}
+			return nil
+		})
+	case "edge":
+		l.hasEdges = l.hasEdges || e.count > 0
+		return l.edges(e)
+	}
+	return l.lists(e, func(property, []float64) error { return nil })
+}
+
+func (l *loader) vertices(e element) error {
+	m := &l.model
+	var has [4]bool
+	var position [3]bool
+	for _, p := range e.properties {
+		if a, ok := vertexAttributes[p.name]; ok && p.countType == 0 {
+			has[a.stream] = true
+			if a.stream == streamPosition {
+				position[a.component] = true
+			}
+		}
+	}
+	// every vertex reads its position before it is appended, so a
+	// huge count fails at the end of the input instead of allocating
+	if position != [3]bool{true, true, true} {
+		return fmt.Errorf("missing x, y or z: %w", objloader.ErrSyntax)
+	}
+
+	for i := 0; i < e.count; i++ {
+		// colours default to white for files only missing some
+		// channels
+		var values [4][3]float32
+		values[streamColor] = [3]float32{1, 1, 1}
+		for _, p := range e.properties {
+			if p.countType != 0 {
+				if err := l.skipList(p); err != nil {
+					return fmt.Errorf("%d: %w", i, err)
+				}
+				continue
+			}
+			v, err := l.values.read(p.typ)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			a, ok := vertexAttributes[p.name]
+			if !ok {
+				continue
+			}
+			if a.stream == streamColor {
+				v /= p.typ.max()
+			}
+			values[a.stream][a.component] = float32(v)
+		}
+
+		m.Vertices = append(m.Vertices, values[streamPosition])
+		if has[streamNormal] {
+			m.Normals = append(m.Normals, values[streamNormal])
+		}
+		if has[streamColor] {
+			m.Colors = append(m.Colors, values[streamColor])
+		}
+		if has[streamTexCoord] {
+			m.TextureCoords = append(m.TextureCoords, values[streamTexCoord])
+		}
+	}
+	return nil
+}
+
+func (l *loader) edges(e element) error {
+	var has [2]bool
+	for _, p := range e.properties {
+		switch {
+		case p.countType != 0:
+		case p.name == "vertex1":
+			has[0] = true
+		case p.name == "vertex2":
+			has[1] = true
+		}
+	}
+	if has != [2]bool{true, true} {
+		return fmt.Errorf("missing vertex1 or vertex2: %w", objloader.ErrSyntax)
+	}
+
+	for i := 0; i < e.count; i++ {
+		var edge [2]uint32
+		for _, p := range e.properties {
+			if p.countType != 0 {
+				if err := l.skipList(p); err != nil {
+					return fmt.Errorf("%d: %w", i, err)
+				}
+				continue
+			}
+			v, err := l.values.read(p.typ)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			switch p.name {
+			case "vertex1":
+				edge[0], err = index(v)
+			case "vertex2":
+				edge[1], err = index(v)
+			}
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		l.model.LineIndices = append(l.model.LineIndices, edge[0], edge[1])
+	}
+	return nil
+}
+
+// lists reads the instances of e, passing every list property to fn.
+func (l *loader) lists(e element, fn func(p property, list []float64) error) error {
+	// instances without properties take no input, however many
+	if len(e.properties) == 0 {
+		return nil
+	}
+
+	list := []float64{}
+	for i := 0; i < e.count; i++ {
+		for _, p := range e.properties {
+			if p.countType == 0 {
+				if _, err := l.values.read(p.typ); err != nil {
+					return fmt.Errorf("%d: %w", i, err)
+				}
+				continue
+			}
+
+			n, err := l.values.read(p.countType)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			list = list[:0]
+			for j := 0; j < int(n); j++ {
+				v, err := l.values.read(p.typ)
+				if err != nil {
+					return fmt.Errorf("%d: %w", i, err)
+				}
+				list = append(list, v)
+			}
+			if err := fn(p, list); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) skipList(p property) error {
+	n, err := l.values.read(p.countType)
+	if err != nil {
+		return err
+	}
+	for j := 0; j < int(n); j++ {
+		if _, err := l.values.read(p.typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// index converts a vertex index read as float64.
+func index(v float64) (uint32, error) {
+	if v < 0 || v > math.MaxUint32-1 || v != math.Trunc(v) {
+		return 0, fmt.Errorf("vertex index %v: %w", v, objloader.ErrIndexOutOfRange)
+	}
+	return uint32(v), nil
+}
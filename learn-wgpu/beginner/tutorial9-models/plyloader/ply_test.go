@@ -0,0 +1,107 @@
+package plyloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestLoadReader(t *testing.T) {
+	const triangle = `ply
+format ascii 1.0
+element vertex 3
+property float x
+property float y
+property float z
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0
+1 0 0
+0 1 0
+3 0 1 2
+`
+	m, err := LoadReader(strings.NewReader(triangle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Vertices) != 3 || len(m.Indices) != 3 {
+		t.Errorf("%d vertices and %d indices, want 3 and 3", len(m.Vertices), len(m.Indices))
+	}
+}
+
+func TestLoadReaderBinary(t *testing.T) {
+	const header = `ply
+format binary_big_endian 1.0
+comment a quad with normals and colours
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+property uchar alpha
+element face 1
+property list uchar int vertex_indices
+end_header
+`
+	positions := [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	colors := [][4]uint8{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 51, 0, 0}}
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	for i := range positions {
+		binary.Write(&b, binary.BigEndian, positions[i])
+		binary.Write(&b, binary.BigEndian, [3]float32{0, 0, 1})
+		b.Write(colors[i][:])
+	}
+	b.WriteByte(4)
+	binary.Write(&b, binary.BigEndian, []int32{0, 1, 2, 3})
+
+	m, err := LoadReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(m.Vertices, positions) {
+		t.Errorf("positions %v, want %v", m.Vertices, positions)
+	}
+	if want := [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}}; !slices.Equal(m.Normals, want) {
+		t.Errorf("normals %v, want %v", m.Normals, want)
+	}
+	if want := [][3]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0.2, 0}}; !slices.Equal(m.Colors, want) {
+		t.Errorf("colours %v, want %v", m.Colors, want)
+	}
+	if want := []uint32{0, 1, 2, 0, 2, 3}; !slices.Equal(m.Indices, want) {
+		t.Errorf("indices %v, want %v", m.Indices, want)
+	}
+}
+
+func TestLoadReaderMalformed(t *testing.T) {
+	for _, tt := range []struct {
+		name, data string
+	}{
+		{"vertices without properties", "ply\nformat ascii 1.0\nelement vertex 50000000\nend_header\n"},
+		{"vertices without z", "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nend_header\n0 0\n"},
+		{"vertices beyond input", "ply\nformat ascii 1.0\nelement vertex 50000000\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n"},
+		{"binary vertices beyond input", "ply\nformat binary_little_endian 1.0\nelement vertex 50000000\nproperty float x\nproperty float y\nproperty float z\nend_header\n"},
+		{"edges without properties", "ply\nformat ascii 1.0\nelement edge 50000000\nend_header\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadReader(strings.NewReader(tt.data)); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+
+	// elements without properties take no input
+	if _, err := LoadReader(strings.NewReader("ply\nformat ascii 1.0\nelement other 9223372036854775807\nend_header\n")); err != nil {
+		t.Errorf("element without properties: %v", err)
+	}
+}
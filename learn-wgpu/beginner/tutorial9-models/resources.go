@@ -2,10 +2,20 @@ package main
 
 import (
 	"embed"
+	"encoding/binary"
 	"errors"
-	"io"
+	"fmt"
+	"image"
+	"io/fs"
+	"path"
+	"strings"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/gltfloader"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/meshutil"
 	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/plyloader"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/stlloader"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 	"golang.org/x/exp/slices"
 )
@@ -13,30 +23,187 @@ import (
 //go:embed res
 var res embed.FS
 
-func loadTexture(name string, device *wgpu.Device, queue *wgpu.Queue) (*Texture, error) {
-	f, err := res.Open("res/" + name)
+// modelFile is the model loaded by LoadModel, obj, glTF, GLB, PLY and
+// STL files are supported.
+const modelFile = "res/cube.obj"
+
+// materialSource is a material as read from a model file, before its
+// texture is uploaded.
+type materialSource struct {
+	Name string
+	// DiffuseTexture is an encoded image, DiffuseColor is used instead
+	// if it is nil.
+	DiffuseTexture []byte
+	// DiffuseColor is a linear RGBA colour.
+	DiffuseColor [4]float32
+	// Sampler is nil for the default sampler.
+	Sampler *wgpu.SamplerDescriptor
+}
+
+// loadModelFile loads name from res by its extension, the glTF
+// document is nil for other formats.
+func loadModelFile(name string) ([]objloader.Model, []materialSource, *gltfloader.Document, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".obj":
+		models, materials, err := loadObj(name)
+		return models, materials, nil, err
+	case ".gltf", ".glb":
+		return loadGltf(name)
+	case ".ply", ".stl":
+		m, err := loadMesh(name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// neither format has materials, the submesh refers to the
+		// default one by an empty name
+		return []objloader.Model{m}, []materialSource{{DiffuseColor: [4]float32{1, 1, 1, 1}}}, nil, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unsupported model format: %s", name)
+}
+
+func loadObj(name string) ([]objloader.Model, []materialSource, error) {
+	models, objMaterials, err := objloader.LoadObj(res, name, &objloader.LoadOptions{
+		GenerateNormals: objloader.NormalsSmooth,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer f.Close()
 
-	buf, err := io.ReadAll(f)
+	materials := []materialSource{}
+	for _, m := range objMaterials {
+		material := materialSource{
+			Name:         m.Name,
+			DiffuseColor: [4]float32{m.Diffuse[0], m.Diffuse[1], m.Diffuse[2], 1},
+		}
+		if m.DiffuseTexture.Path != "" {
+			material.DiffuseTexture, err = fs.ReadFile(res, path.Join(path.Dir(name), m.DiffuseTexture.Path))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		materials = append(materials, material)
+	}
+	return models, materials, nil
+}
+
+func loadMesh(name string) (objloader.Model, error) {
+	if strings.ToLower(path.Ext(name)) == ".stl" {
+		return stlloader.Load(res, name, nil)
+	}
+	return plyloader.Load(res, name)
+}
+
+func loadGltf(name string) ([]objloader.Model, []materialSource, *gltfloader.Document, error) {
+	doc, err := gltfloader.Load(res, name)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+
+	materials := []materialSource{}
+	for _, m := range doc.Materials {
+		material := materialSource{Name: m.Name, DiffuseColor: m.BaseColorFactor}
+		// the shader has no base colour factor, so it is ignored for
+		// textured materials
+		if t := m.BaseColorTexture.Texture; t != -1 && doc.Textures[t].Image != -1 {
+			texture := doc.Textures[t]
+			material.DiffuseTexture = doc.Images[texture.Image].Data
+			if texture.Sampler != -1 {
+				material.Sampler = gltfSampler(doc.Samplers[texture.Sampler])
+			} else {
+				material.Sampler = gltfSampler(gltfloader.Sampler{WrapS: gltfloader.WrapRepeat, WrapT: gltfloader.WrapRepeat})
+			}
+		}
+		materials = append(materials, material)
+	}
+
+	models := doc.Flatten(-1)
+	// primitives without a material refer to the default one by an
+	// empty name
+	for _, m := range models {
+		if slices.ContainsFunc(m.Submeshes, func(sm objloader.Submesh) bool { return sm.MaterialName == "" }) {
+			materials = append(materials, materialSource{DiffuseColor: [4]float32{1, 1, 1, 1}})
+			break
+		}
+	}
+	return models, materials, doc, nil
+}
+
+// gltfSampler converts s to a sampler descriptor, unspecified filters
+// are linear.
+func gltfSampler(s gltfloader.Sampler) *wgpu.SamplerDescriptor {
+	addressMode := func(wrap int) wgpu.AddressMode {
+		switch wrap {
+		case gltfloader.WrapClampToEdge:
+			return wgpu.AddressMode_ClampToEdge
+		case gltfloader.WrapMirroredRepeat:
+			return wgpu.AddressMode_MirrorRepeat
+		}
+		return wgpu.AddressMode_Repeat
 	}
 
-	return TextureFromBytes(device, queue, buf, name)
+	desc := &wgpu.SamplerDescriptor{
+		AddressModeU:   addressMode(s.WrapS),
+		AddressModeV:   addressMode(s.WrapT),
+		AddressModeW:   wgpu.AddressMode_Repeat,
+		MagFilter:      wgpu.FilterMode_Linear,
+		MinFilter:      wgpu.FilterMode_Linear,
+		MipmapFilter:   wgpu.MipmapFilterMode_Linear,
+		LodMaxClamp:    32,
+		MaxAnisotrophy: 1,
+	}
+	if s.MagFilter == gltfloader.FilterNearest {
+		desc.MagFilter = wgpu.FilterMode_Nearest
+	}
+	switch s.MinFilter {
+	case gltfloader.FilterNearest, gltfloader.FilterNearestMipmapNearest:
+		desc.MinFilter = wgpu.FilterMode_Nearest
+		desc.MipmapFilter = wgpu.MipmapFilterMode_Nearest
+	case gltfloader.FilterNearestMipmapLinear:
+		desc.MinFilter = wgpu.FilterMode_Nearest
+	case gltfloader.FilterLinearMipmapNearest:
+		desc.MipmapFilter = wgpu.MipmapFilterMode_Nearest
+	}
+	return desc
 }
 
-func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLayout) (*Model, error) {
-	models, objMaterials, err := objloader.LoadObj(res, "res/cube.obj")
+// createMaterialTexture uploads the diffuse texture of m, or a single
+// pixel of its colour if it has none.
+func createMaterialTexture(device *wgpu.Device, queue *wgpu.Queue, m materialSource) (*Texture, error) {
+	var texture *Texture
+	var err error
+	if m.DiffuseTexture != nil {
+		texture, err = TextureFromBytes(device, queue, m.DiffuseTexture, m.Name)
+	} else {
+		c := glm.Color[float32]{R: m.DiffuseColor[0], G: m.DiffuseColor[1], B: m.DiffuseColor[2], A: m.DiffuseColor[3]}.ToSRGB()
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		img.Pix = binary.LittleEndian.AppendUint32(img.Pix[:0], c.PackRGBA8())
+		texture, err = TextureFromImage(device, queue, img, m.Name)
+	}
+	if err != nil || m.Sampler == nil {
+		return texture, err
+	}
+
+	sampler, err := device.CreateSampler(m.Sampler)
+	if err != nil {
+		texture.Destroy()
+		return nil, err
+	}
+	texture.sampler.Release()
+	texture.sampler = sampler
+	return texture, nil
+}
+
+// LoadModel loads modelFile, its vertex colours are converted to the
+// colour space the surface format expects.
+func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLayout, format wgpu.TextureFormat) (*Model, error) {
+	models, materialSources, doc, err := loadModelFile(modelFile)
 	if err != nil {
 		return nil, err
 	}
 
 	materials := []Material{}
-	for _, m := range objMaterials {
-		diffuseTexture, err := loadTexture(m.DiffuseTexture, device, queue)
+	for _, m := range materialSources {
+		diffuseTexture, err := createMaterialTexture(device, queue, m)
 		if err != nil {
 			return nil, err
 		}
@@ -65,62 +232,263 @@ func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLay
 		})
 	}
 
+	// meshes with morph targets are morphed again by Skeleton.Update,
+	// which needs the vertex order of gltfloader.Flatten, so they
+	// aren't optimised
+	morphed := make([]bool, len(models))
+	if doc != nil {
+		for i, inst := range doc.MeshInstances(-1) {
+			morphed[i] = len(doc.MorphTargets[inst.Mesh]) != 0
+		}
+	}
+
 	meshes := []Mesh{}
+	positions := []glm.Vec3[float32]{}
 
-	for _, m := range models {
-		if len(m.Normals) != len(m.TextureCoords) || len(m.TextureCoords) != len(m.Vertices) {
-			return nil, errors.New("got invalid obj")
+	for mi := range models {
+		m := &models[mi]
+		if m.HasTextureCoords() && m.HasNormals() && len(m.Tangents) != len(m.Vertices) {
+			if err := meshutil.GenerateTangents(m); err != nil {
+				return nil, err
+			}
+		}
+		if !morphed[mi] {
+			meshutil.Optimize(m)
+		}
+		// sRGB surfaces encode the shader output, so they take linear
+		// colours and the others sRGB encoded ones
+		if srgb := glm.IsSRGBFormat(format); srgb != m.LinearColors {
+			for i, c := range m.Colors {
+				color := glm.Color[float32]{R: c[0], G: c[1], B: c[2]}
+				if srgb {
+					color = color.ToLinear()
+				} else {
+					color = color.ToSRGB()
+				}
+				m.Colors[i] = color.Vec3()
+			}
 		}
 
-		vertices := []ModelVertex{}
-		for i := 0; i < len(m.Vertices); i++ {
-			pos := m.Vertices[i]
-			texCoords := m.TextureCoords[i]
-			normal := m.Normals[i]
-
-			vertices = append(vertices, ModelVertex{
-				Position:  pos,
-				TexCoords: [2]float32{texCoords[0], texCoords[1]},
-				Normal:    normal,
-			})
+		vertices := modelVertices(m)
+		for _, v := range m.Vertices {
+			positions = append(positions, v)
 		}
 
 		vertexBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
 			Label:    m.Name + " vertex buffer",
 			Contents: wgpu.ToBytes(vertices),
-			Usage:    wgpu.BufferUsage_Vertex,
+			// rewritten by morphing and CPU skinning
+			Usage: wgpu.BufferUsage_Vertex | wgpu.BufferUsage_CopyDst,
 		})
 		if err != nil {
 			return nil, err
 		}
 
-		indexBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-			Label:    m.Name + " index buffer",
-			Contents: wgpu.ToBytes(m.Indices),
-			Usage:    wgpu.BufferUsage_Index,
-		})
+		indexFormat := wgpu.IndexFormat_Uint32
+		if len(m.Vertices) <= meshutil.MaxUint16Vertices {
+			indexFormat = wgpu.IndexFormat_Uint16
+		}
+
+		indexBuffer, err := createIndexBuffer(device, m.Name+" index buffer", m.Indices, indexFormat)
+		if err != nil {
+			return nil, err
+		}
+		lineIndexBuffer, err := createIndexBuffer(device, m.Name+" line index buffer", m.LineIndices, indexFormat)
+		if err != nil {
+			return nil, err
+		}
+		pointIndexBuffer, err := createIndexBuffer(device, m.Name+" point index buffer", m.PointIndices, indexFormat)
 		if err != nil {
 			return nil, err
 		}
 
-		materialIdx := slices.IndexFunc(materials,
-			func(e Material) bool { return e.Name == m.MaterialName },
-		)
-		if materialIdx == -1 {
-			materialIdx = 0
+		lods, err := createLods(device, m, indexFormat, materials)
+		if err != nil {
+			return nil, err
 		}
 
 		meshes = append(meshes, Mesh{
 			Name:         m.Name,
 			VertexBuffer: vertexBuffer,
 			IndexBuffer:  indexBuffer,
+			IndexFormat:  indexFormat,
 			NumElements:  uint32(len(m.Indices)),
-			MaterialIdx:  materialIdx,
+			Submeshes:    submeshes(m.Submeshes, materials),
+			Lods:         lods,
+
+			LineIndexBuffer:  lineIndexBuffer,
+			NumLineElements:  uint32(len(m.LineIndices)),
+			PointIndexBuffer: pointIndexBuffer,
+			NumPointElements: uint32(len(m.PointIndices)),
+			Morphed:          morphed[mi],
 		})
 	}
 
+	// the error of a level is the largest of its meshes, meshes with
+	// fewer levels use their coarsest one
+	lodErrors := []float32{}
+	for _, mesh := range meshes {
+		for level := len(lodErrors); level < len(mesh.Lods); level++ {
+			lodErrors = append(lodErrors, 0)
+		}
+	}
+	for level := range lodErrors {
+		for _, mesh := range meshes {
+			if len(mesh.Lods) == 0 {
+				continue
+			}
+			lod := mesh.Lods[len(mesh.Lods)-1]
+			if level < len(mesh.Lods) {
+				lod = mesh.Lods[level]
+			}
+			if lod.Error > lodErrors[level] {
+				lodErrors[level] = lod.Error
+			}
+		}
+	}
+
 	return &Model{
 		Meshes:    meshes,
 		Materials: materials,
+		Bounds:    glm.AABBFromPoints(positions),
+		ObjModels: models,
+		Document:  doc,
+		LodErrors: lodErrors,
 	}, nil
 }
+
+// modelVertices interleaves the vertex streams of m, attributes
+// missing from the model are left zeroed and colours white.
+func modelVertices(m *objloader.Model) []ModelVertex {
+	hasTexCoords, hasNormals := m.HasTextureCoords(), m.HasNormals()
+	hasTangents := len(m.Tangents) == len(m.Vertices)
+	hasColors, hasSkin := m.HasColors(), m.HasSkin()
+
+	vertices := []ModelVertex{}
+	for i := 0; i < len(m.Vertices); i++ {
+		var texCoords, normal [3]float32
+		if hasTexCoords {
+			texCoords = m.TextureCoords[i]
+		}
+		if hasNormals {
+			normal = m.Normals[i]
+		}
+		var tangent [4]float32
+		if hasTangents {
+			tangent = m.Tangents[i]
+		}
+		color := [3]float32{1, 1, 1}
+		if hasColors {
+			color = m.Colors[i]
+		}
+		var joints [4]uint16
+		var weights [4]float32
+		if hasSkin {
+			joints, weights = m.Joints[i], m.Weights[i]
+		}
+
+		vertices = append(vertices, ModelVertex{
+			Position:  m.Vertices[i],
+			TexCoords: glm.Vec3[float32](texCoords).Truncate(),
+			Normal:    normal,
+			Tangent:   tangent,
+			Color:     color,
+			Joints:    joints,
+			Weights:   weights,
+		})
+	}
+	return vertices
+}
+
+// MaxLods is the number of coarser levels of detail built per mesh.
+const MaxLods = 3
+
+// createLods builds a chain of levels of detail with half the
+// triangles of the previous one each, as long as simplification
+// keeps within 2% of the mesh size and saves at least a fifth of the
+// triangles.
+func createLods(device *wgpu.Device, m *objloader.Model, indexFormat wgpu.IndexFormat, materials []Material) ([]Lod, error) {
+	points := make([]glm.Vec3[float32], len(m.Vertices))
+	for i, v := range m.Vertices {
+		points[i] = v
+	}
+	bounds := glm.AABBFromPoints(points)
+	maxError := bounds.Max.Sub(bounds.Min).Magnitude() * 0.02
+
+	lods := []Lod{}
+	triangles := len(m.Indices) / 3
+	for level := 1; level <= MaxLods; level++ {
+		lod, lodError := meshutil.Simplify(m, &meshutil.SimplifyOptions{
+			TargetTriangles: len(m.Indices) / 3 >> level,
+			MaxError:        maxError,
+		})
+		if len(lod.Indices)/3 > triangles*4/5 {
+			break
+		}
+		triangles = len(lod.Indices) / 3
+
+		for _, sm := range lod.Submeshes {
+			meshutil.OptimizeVertexCache(lod.Indices[sm.IndexOffset:sm.IndexOffset+sm.IndexCount], len(lod.Vertices))
+		}
+
+		indexBuffer, err := createIndexBuffer(device, fmt.Sprintf("%s lod %d index buffer", m.Name, level), lod.Indices, indexFormat)
+		if err != nil {
+			for _, lod := range lods {
+				lod.IndexBuffer.Release()
+			}
+			return nil, err
+		}
+
+		lods = append(lods, Lod{
+			IndexBuffer: indexBuffer,
+			NumElements: uint32(len(lod.Indices)),
+			Submeshes:   submeshes(lod.Submeshes, materials),
+			Error:       lodError,
+		})
+	}
+	return lods, nil
+}
+
+// submeshes resolves the materials of sms by name, unknown ones use
+// the first material.
+func submeshes(sms []objloader.Submesh, materials []Material) []Submesh {
+	submeshes := []Submesh{}
+	for _, sm := range sms {
+		materialIdx := slices.IndexFunc(materials,
+			func(e Material) bool { return e.Name == sm.MaterialName },
+		)
+		if materialIdx == -1 {
+			materialIdx = 0
+		}
+
+		submeshes = append(submeshes, Submesh{
+			FirstIndex:  sm.IndexOffset,
+			NumElements: sm.IndexCount,
+			MaterialIdx: materialIdx,
+		})
+	}
+	return submeshes
+}
+
+// createIndexBuffer returns a nil buffer for empty indices, as
+// zero-sized buffers can't be bound.
+func createIndexBuffer(device *wgpu.Device, label string, indices []uint32, format wgpu.IndexFormat) (*wgpu.Buffer, error) {
+	if len(indices) == 0 {
+		return nil, nil
+	}
+
+	contents := wgpu.ToBytes(indices)
+	if format == wgpu.IndexFormat_Uint16 {
+		compact, ok := meshutil.CompactIndices(indices)
+		if !ok {
+			return nil, errors.New("index out of range for 16-bit index buffer")
+		}
+		contents = wgpu.ToBytes(compact)
+	}
+
+	return device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    label,
+		Contents: contents,
+		Usage:    wgpu.BufferUsage_Index,
+	})
+}
@@ -6,20 +6,31 @@ struct Camera {
 @group(1) @binding(0)
 var<uniform> camera: Camera;
 
+// joint matrices of all skins, see gltfloader.Document.JointMatrices
+@group(2) @binding(0)
+var<storage, read> joints: array<mat4x4<f32>>;
+
 struct VertexInput {
     @location(0) position: vec3<f32>,
     @location(1) tex_coords: vec2<f32>,
+    @location(4) color: vec3<f32>,
+    @location(12) joints: vec4<u32>,
+    @location(13) weights: vec4<f32>,
 }
 struct InstanceInput {
     @location(5) model_matrix_0: vec4<f32>,
     @location(6) model_matrix_1: vec4<f32>,
     @location(7) model_matrix_2: vec4<f32>,
     @location(8) model_matrix_3: vec4<f32>,
+    @location(9) normal_matrix_0: vec3<f32>,
+    @location(10) normal_matrix_1: vec3<f32>,
+    @location(11) normal_matrix_2: vec3<f32>,
 }
 
 struct VertexOutput {
     @builtin(position) clip_position: vec4<f32>,
     @location(0) tex_coords: vec2<f32>,
+    @location(1) color: vec3<f32>,
 }
 
 @vertex
@@ -33,9 +44,23 @@ fn vs_main(
         instance.model_matrix_2,
         instance.model_matrix_3,
     );
+    // linear blend skinning, vertices without weights aren't skinned
+    var skin_matrix = mat4x4<f32>(
+        vec4<f32>(1.0, 0.0, 0.0, 0.0),
+        vec4<f32>(0.0, 1.0, 0.0, 0.0),
+        vec4<f32>(0.0, 0.0, 1.0, 0.0),
+        vec4<f32>(0.0, 0.0, 0.0, 1.0),
+    );
+    if any(model.weights != vec4<f32>(0.0)) {
+        skin_matrix = joints[model.joints.x] * model.weights.x
+            + joints[model.joints.y] * model.weights.y
+            + joints[model.joints.z] * model.weights.z
+            + joints[model.joints.w] * model.weights.w;
+    }
     var out: VertexOutput;
     out.tex_coords = model.tex_coords;
-    out.clip_position = camera.view_proj * model_matrix * vec4<f32>(model.position, 1.0);
+    out.color = model.color;
+    out.clip_position = camera.view_proj * model_matrix * skin_matrix * vec4<f32>(model.position, 1.0);
     return out;
 }
 
@@ -48,5 +73,10 @@ var s_diffuse: sampler;
 
 @fragment
 fn fs_main(in: VertexOutput) -> @location(0) vec4<f32> {
-    return textureSample(t_diffuse, s_diffuse, in.tex_coords);
+    return textureSample(t_diffuse, s_diffuse, in.tex_coords) * vec4<f32>(in.color, 1.0);
+}
+
+@fragment
+fn fs_color(in: VertexOutput) -> @location(0) vec4<f32> {
+    return vec4<f32>(in.color, 1.0);
 }
\ No newline at end of file
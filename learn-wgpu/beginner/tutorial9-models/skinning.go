@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/meshutil"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Skeleton plays the first animation of a glTF model, uploads the
+// joint matrices of its skins to a storage buffer and rewrites the
+// vertex buffers of its morphed meshes.
+type Skeleton struct {
+	model    *Model
+	time     float32
+	matrices []glm.Mat4[float32]
+	// posed is set once the first pose is uploaded, models without
+	// animations keep it
+	posed bool
+	// cpuSkinning skins the vertices with meshutil.Skin and rewrites
+	// the vertex buffers every frame instead of skinning in the vertex
+	// shader, which then gets identity joint matrices. Both paths
+	// should draw the same image.
+	cpuSkinning bool
+
+	JointBuffer *wgpu.Buffer
+	BindGroup   *wgpu.BindGroup
+}
+
+// NewSkeleton creates the joint matrices of model. Models without
+// skins get a single identity matrix, as bindings can't be empty.
+func NewSkeleton(device *wgpu.Device, layout *wgpu.BindGroupLayout, model *Model, cpuSkinning bool) (s *Skeleton, err error) {
+	defer func() {
+		if err != nil {
+			s.Destroy()
+			s = nil
+		}
+	}()
+	s = &Skeleton{model: model, cpuSkinning: cpuSkinning}
+
+	if model.Document != nil {
+		s.matrices = model.Document.JointMatrices(s.matrices)
+	}
+	if len(s.matrices) == 0 {
+		s.matrices = append(s.matrices, glm.Mat4Identity[float32]())
+	}
+
+	s.JointBuffer, err = device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    "Joint Buffer",
+		Contents: wgpu.ToBytes(s.uploadedMatrices()),
+		Usage:    wgpu.BufferUsage_Storage | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return s, err
+	}
+
+	s.BindGroup, err = device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Label:  "JointBindGroup",
+		Layout: layout,
+		Entries: []wgpu.BindGroupEntry{{
+			Binding: 0,
+			Buffer:  s.JointBuffer,
+			Size:    wgpu.WholeSize,
+		}},
+	})
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// uploadedMatrices returns the joint matrices for the vertex shader.
+func (s *Skeleton) uploadedMatrices() []glm.Mat4[float32] {
+	if !s.cpuSkinning {
+		return s.matrices
+	}
+	identity := make([]glm.Mat4[float32], len(s.matrices))
+	for i := range identity {
+		identity[i] = glm.Mat4Identity[float32]()
+	}
+	return identity
+}
+
+// Update advances the animation by dt seconds and uploads the new
+// pose. Models without animations are posed by the first call.
+func (s *Skeleton) Update(queue *wgpu.Queue, dt float32) error {
+	doc := s.model.Document
+	animated := doc != nil && len(doc.Animations) != 0
+	if doc == nil || s.posed && !animated {
+		return nil
+	}
+	s.posed = true
+
+	if animated {
+		s.time += dt
+		doc.Animate(0, doc.Animations[0].Start+s.time)
+	}
+	if len(doc.Skins) != 0 {
+		s.matrices = doc.JointMatrices(s.matrices[:0])
+		if !s.cpuSkinning {
+			queue.WriteBuffer(s.JointBuffer, 0, wgpu.ToBytes(s.matrices))
+		}
+	}
+
+	// morphed meshes are flattened again with the animated weights
+	var flattened []objloader.Model
+	for i := range s.model.ObjModels {
+		m := s.model.ObjModels[i]
+		morphed, skinned := s.model.Meshes[i].Morphed, s.cpuSkinning && m.HasSkin()
+		if !morphed && !skinned {
+			continue
+		}
+
+		if morphed {
+			if flattened == nil {
+				flattened = doc.Flatten(-1)
+			}
+			if err := morphVertices(&m, flattened[i]); err != nil {
+				return err
+			}
+		}
+		if skinned {
+			var err error
+			if m, err = meshutil.Skin(&m, s.matrices); err != nil {
+				return err
+			}
+		}
+		queue.WriteBuffer(s.model.Meshes[i].VertexBuffer, 0, wgpu.ToBytes(modelVertices(&m)))
+	}
+	return nil
+}
+
+// morphVertices replaces the vertices, normals and tangents of m with
+// those of flat, the mesh m was loaded from as flattened in the
+// current pose.
+func morphVertices(m *objloader.Model, flat objloader.Model) error {
+	// the vertices split by tangent generation only depend on the
+	// texture coordinates, so generating them again splits the same
+	if len(m.Tangents) == len(m.Vertices) && len(flat.Tangents) != len(flat.Vertices) {
+		if err := meshutil.GenerateTangents(&flat); err != nil {
+			return err
+		}
+	}
+	if len(flat.Vertices) != len(m.Vertices) {
+		return errors.New("morphed mesh doesn't match its vertex buffer")
+	}
+	m.Vertices, m.Normals, m.Tangents = flat.Vertices, flat.Normals, flat.Tangents
+	return nil
+}
+
+func (s *Skeleton) Destroy() {
+	if s.BindGroup != nil {
+		s.BindGroup.Release()
+		s.BindGroup = nil
+	}
+	if s.JointBuffer != nil {
+		s.JointBuffer.Release()
+		s.JointBuffer = nil
+	}
+}
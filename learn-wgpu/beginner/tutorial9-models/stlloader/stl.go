@@ -0,0 +1,288 @@
+// Package stlloader loads ASCII and binary STL files into objloader
+// models, welding the vertices of the separate facets.
+package stlloader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"strconv"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
+)
+
+// DefaultCreaseAngle is used if LoadOptions.CreaseAngle is zero.
+const DefaultCreaseAngle = math.Pi / 6
+
+// LoadOptions controls how the facets are welded.
+type LoadOptions struct {
+	// CreaseAngle in radians is the largest angle between facets
+	// whose vertices are welded and normals smoothed, pass math.Pi to
+	// weld every vertex.
+	CreaseAngle float32
+}
+
+type facet struct {
+	normal   glm.Vec3[float32]
+	vertices [3]glm.Vec3[float32]
+	// color is only valid if the file has colours
+	color [3]float32
+}
+
+// Load loads the STL file name from dir, opts may be nil.
+func Load(dir fs.FS, name string, opts *LoadOptions) (objloader.Model, error) {
+	f, err := dir.Open(name)
+	if err != nil {
+		return objloader.Model{}, err
+	}
+	defer f.Close()
+
+	m, err := LoadReader(f, opts)
+	if err != nil {
+		return m, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+// LoadReader loads an ASCII or binary STL file from r, opts may be
+// nil. Binary files are told apart by their size matching their
+// triangle count, as some begin with "solid" as well.
+//
+// Facet colours of binary files, in either the VisCAM or the
+// Materialise convention, become vertex colours. Vertices with the
+// same position and colour are welded unless their facets meet at
+// more than the crease angle, their normals are the area weighted
+// average of those facets.
+func LoadReader(r io.Reader, opts *LoadOptions) (objloader.Model, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return objloader.Model{}, err
+	}
+
+	var (
+		facets    []facet
+		hasColors bool
+		name      string
+	)
+	if isBinary(data) {
+		facets, hasColors, err = readBinary(data)
+	} else {
+		name, facets, err = readASCII(data)
+	}
+	if err != nil {
+		return objloader.Model{}, err
+	}
+
+	creaseAngle := float32(DefaultCreaseAngle)
+	if opts != nil && opts.CreaseAngle != 0 {
+		creaseAngle = opts.CreaseAngle
+	}
+	m := weld(facets, hasColors, creaseAngle)
+	m.Name = name
+	return m, nil
+}
+
+func isBinary(data []byte) bool {
+	if len(data) < 84 {
+		return false
+	}
+	count := binary.LittleEndian.Uint32(data[80:])
+	return uint64(len(data)) == 84+50*uint64(count)
+}
+
+func readBinary(data []byte) ([]facet, bool, error) {
+	header := data[:80]
+	count := binary.LittleEndian.Uint32(data[80:])
+	data = data[84:]
+
+	// Materialise files declare colours in the header, with the valid
+	// bit inverted and red and blue swapped compared to VisCAM
+	materialise := bytes.Contains(header, []byte("COLOR="))
+	hasColors := false
+
+	facets := make([]facet, count)
+	for i := range facets {
+		b := data[i*50:]
+		var vectors [4]glm.Vec3[float32]
+		for v := range vectors {
+			for c := 0; c < 3; c++ {
+				vectors[v][c] = math.Float32frombits(binary.LittleEndian.Uint32(b[v*12+c*4:]))
+			}
+		}
+		facets[i] = facet{normal: vectors[0], vertices: [3]glm.Vec3[float32]{vectors[1], vectors[2], vectors[3]}}
+
+		attribute := binary.LittleEndian.Uint16(b[48:])
+		valid := attribute&0x8000 != 0
+		if materialise {
+			valid = !valid
+		}
+		if !valid {
+			facets[i].color = [3]float32{1, 1, 1}
+			continue
+		}
+		hasColors = true
+		lo, mid, hi := float32(attribute&0x1f)/31, float32(attribute>>5&0x1f)/31, float32(attribute>>10&0x1f)/31
+		if materialise {
+			facets[i].color = [3]float32{lo, mid, hi}
+		} else {
+			facets[i].color = [3]float32{hi, mid, lo}
+		}
+	}
+	return facets, hasColors, nil
+}
+
+func readASCII(data []byte) (string, []facet, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(bufio.ScanWords)
+	facets := []facet{}
+
+	expect := func(words ...string) error {
+		for _, w := range words {
+			if !scanner.Scan() {
+				return fmt.Errorf("stl: missing %s: %w", w, objloader.ErrSyntax)
+			}
+			if scanner.Text() != w {
+				return fmt.Errorf("stl: %q, want %s: %w", scanner.Text(), w, objloader.ErrSyntax)
+			}
+		}
+		return nil
+	}
+	vector := func() (v glm.Vec3[float32], err error) {
+		for c := range v {
+			if !scanner.Scan() {
+				return v, fmt.Errorf("stl: %w", objloader.ErrMissingValue)
+			}
+			f, err := strconv.ParseFloat(scanner.Text(), 32)
+			if err != nil {
+				return v, fmt.Errorf("stl: %q: %w", scanner.Text(), objloader.ErrSyntax)
+			}
+			v[c] = float32(f)
+		}
+		return v, nil
+	}
+
+	if err := expect("solid"); err != nil {
+		return "", nil, err
+	}
+	// the name is the rest of the first line, which the word scanner
+	// can't tell apart, so it is taken from the raw data
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	name := string(bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(line), []byte("solid"))))
+	for scanner.Scan() && scanner.Text() != "facet" && scanner.Text() != "endsolid" {
+	}
+
+	for scanner.Text() == "facet" {
+		var f facet
+		var err error
+		if err = expect("normal"); err != nil {
+			return "", nil, err
+		}
+		if f.normal, err = vector(); err != nil {
+			return "", nil, err
+		}
+		if err = expect("outer", "loop"); err != nil {
+			return "", nil, err
+		}
+		for v := range f.vertices {
+			if err = expect("vertex"); err != nil {
+				return "", nil, err
+			}
+			if f.vertices[v], err = vector(); err != nil {
+				return "", nil, err
+			}
+		}
+		if err = expect("endloop", "endfacet"); err != nil {
+			return "", nil, err
+		}
+		facets = append(facets, f)
+
+		if !scanner.Scan() {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if scanner.Text() != "endsolid" {
+		return "", nil, errors.New("stl: missing endsolid")
+	}
+	return name, facets, nil
+}
+
+// weld merges the corners of facets into shared vertices. The corners
+// at a position form groups of facets within creaseAngle of the first
+// facet of the group, each group becomes a vertex.
+func weld(facets []facet, hasColors bool, creaseAngle float32) objloader.Model {
+	type key struct {
+		position glm.Vec3[float32]
+		color    [3]float32
+	}
+	type group struct {
+		// normal is the unit normal of the first facet
+		normal glm.Vec3[float32]
+		vertex uint32
+	}
+
+	m := objloader.Model{}
+	normals := []glm.Vec3[float32]{}
+	groups := map[key][]group{}
+	cosCrease := float32(math.Cos(float64(creaseAngle)))
+
+	for _, f := range facets {
+		// the stored normal is often zero or stale, so the winding
+		// decides, the stored normal only for degenerate facets
+		n := f.vertices[1].Sub(f.vertices[0]).Cross(f.vertices[2].Sub(f.vertices[0]))
+		area := n.Magnitude()
+		unit := f.normal
+		if area > 0 {
+			unit = n.DivScalar(area)
+		} else if unit.Magnitude() > 0 {
+			unit = unit.Normalize()
+		}
+
+		for _, p := range f.vertices {
+			k := key{position: p}
+			if hasColors {
+				k.color = f.color
+			}
+
+			vertex := -1
+			for _, g := range groups[k] {
+				if g.normal.Dot(unit) >= cosCrease || area == 0 {
+					vertex = int(g.vertex)
+					break
+				}
+			}
+			if vertex == -1 {
+				vertex = len(m.Vertices)
+				groups[k] = append(groups[k], group{normal: unit, vertex: uint32(vertex)})
+				m.Vertices = append(m.Vertices, p)
+				if hasColors {
+					m.Colors = append(m.Colors, f.color)
+				}
+				normals = append(normals, glm.Vec3[float32]{})
+			}
+
+			// the cross product is twice the area
+			normals[vertex] = normals[vertex].Add(n)
+			m.Indices = append(m.Indices, uint32(vertex))
+		}
+	}
+
+	for _, n := range normals {
+		if n.Magnitude() > 0 {
+			n = n.Normalize()
+		}
+		m.Normals = append(m.Normals, n)
+	}
+	if len(m.Indices) != 0 {
+		m.Submeshes = []objloader.Submesh{{IndexCount: uint32(len(m.Indices))}}
+	}
+	return m
+}
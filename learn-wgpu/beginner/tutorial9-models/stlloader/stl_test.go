@@ -0,0 +1,179 @@
+package stlloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+)
+
+// cubeFacets returns the 12 facets of the unit cube, wound counter
+// clockwise from the outside, two per face in the order -x, +x, -y,
+// +y, -z, +z.
+func cubeFacets() [][3]glm.Vec3[float32] {
+	corner := func(i int) glm.Vec3[float32] {
+		return glm.Vec3[float32]{float32(i & 1), float32(i >> 1 & 1), float32(i >> 2 & 1)}
+	}
+	var facets [][3]glm.Vec3[float32]
+	for _, q := range [6][4]int{{0, 4, 6, 2}, {1, 3, 7, 5}, {0, 1, 5, 4}, {2, 6, 7, 3}, {0, 2, 3, 1}, {4, 5, 7, 6}} {
+		facets = append(facets,
+			[3]glm.Vec3[float32]{corner(q[0]), corner(q[1]), corner(q[2])},
+			[3]glm.Vec3[float32]{corner(q[0]), corner(q[2]), corner(q[3])})
+	}
+	return facets
+}
+
+func asciiCube() []byte {
+	var b bytes.Buffer
+	b.WriteString("solid cube\n")
+	for _, f := range cubeFacets() {
+		// a stale normal, the winding decides
+		b.WriteString("facet normal 0 0 0\nouter loop\n")
+		for _, v := range f {
+			fmt.Fprintf(&b, "vertex %v %v %v\n", v[0], v[1], v[2])
+		}
+		b.WriteString("endloop\nendfacet\n")
+	}
+	b.WriteString("endsolid cube\n")
+	return b.Bytes()
+}
+
+// binaryCube returns the cube as a binary file with the given header
+// and facet attributes.
+func binaryCube(header string, attribute func(facet int) uint16) []byte {
+	facets := cubeFacets()
+	b := make([]byte, 80, 84+50*len(facets))
+	copy(b, header)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(facets)))
+	for i, f := range facets {
+		b = append(b, make([]byte, 12)...)
+		for _, v := range f {
+			for _, c := range v {
+				b = binary.LittleEndian.AppendUint32(b, math.Float32bits(c))
+			}
+		}
+		b = binary.LittleEndian.AppendUint16(b, attribute(i))
+	}
+	return b
+}
+
+func TestLoadReaderCube(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		// the header starts with "solid" like many exporters write it
+		{"ascii", asciiCube()},
+		{"binary", binaryCube("solid cube", func(int) uint16 { return 0 })},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, weld := range []struct {
+				creaseAngle float32
+				vertices    int
+			}{
+				{0, 24},
+				{math.Pi, 8},
+			} {
+				m, err := LoadReader(bytes.NewReader(tt.data), &LoadOptions{CreaseAngle: weld.creaseAngle})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(m.Vertices) != weld.vertices || len(m.Normals) != weld.vertices || len(m.Indices) != 36 {
+					t.Errorf("crease angle %v: %d vertices, %d normals and %d indices, want %d, %d and 36",
+						weld.creaseAngle, len(m.Vertices), len(m.Normals), len(m.Indices), weld.vertices, weld.vertices)
+				}
+				if m.HasColors() {
+					t.Errorf("crease angle %v: has colours", weld.creaseAngle)
+				}
+
+				// the normals point away from the centre, along the face
+				// or, area weighted, close to the diagonal
+				center := glm.Vec3[float32]{0.5, 0.5, 0.5}
+				for i, n := range m.Normals {
+					n := glm.Vec3[float32](n)
+					dot := n.Dot(glm.Vec3[float32](m.Vertices[i]).Sub(center).Normalize())
+					face := math.Abs(float64(dot)-1/math.Sqrt(3)) < 1e-5
+					if math.Abs(float64(n.Magnitude())-1) > 1e-5 || face != (weld.vertices == 24) || dot < 0.9 && !face {
+						t.Errorf("crease angle %v: vertex %v: normal %v", weld.creaseAngle, m.Vertices[i], n)
+					}
+				}
+			}
+		})
+	}
+
+	m, err := LoadReader(bytes.NewReader(asciiCube()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "cube" {
+		t.Errorf("name %q, want cube", m.Name)
+	}
+}
+
+func TestLoadReaderColors(t *testing.T) {
+	red := [3]float32{1, 0, 0}
+	white := [3]float32{1, 1, 1}
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		// the attributes of the red facets and of the others
+		red, other uint16
+	}{
+		// the valid bit set and red in the high bits
+		{"VisCAM", "", 0x8000 | 31<<10, 0},
+		// the valid bit cleared and red in the low bits
+		{"Materialise", "COLOR=\xff\xff\xff\xff", 31, 0x8000},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// the -x face is red
+			data := binaryCube(tt.header, func(facet int) uint16 {
+				if facet < 2 {
+					return tt.red
+				}
+				return tt.other
+			})
+			m, err := LoadReader(bytes.NewReader(data), &LoadOptions{CreaseAngle: math.Pi})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !m.HasColors() {
+				t.Fatal("no colours")
+			}
+			// the corners of the red face aren't welded to the rest
+			if len(m.Vertices) != 12 {
+				t.Errorf("%d vertices, want 12", len(m.Vertices))
+			}
+			for i, v := range m.Indices {
+				want := white
+				if i < 6 {
+					want = red
+				}
+				if m.Colors[v] != want {
+					t.Errorf("facet %d: colour %v, want %v", i/3, m.Colors[v], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadReaderMalformed(t *testing.T) {
+	for _, tt := range []struct {
+		name, data string
+	}{
+		{"empty", ""},
+		{"missing endsolid", "solid cube\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\n"},
+		{"missing vertex", "solid cube\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\nendsolid\n"},
+		{"bad number", "solid cube\nfacet normal 0 0 x\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadReader(strings.NewReader(tt.data), nil); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
@@ -159,7 +159,7 @@ func CreateDepthTexture(device *wgpu.Device, config *wgpu.SwapChainDescriptor, l
 		MagFilter:      wgpu.FilterMode_Nearest,
 		MinFilter:      wgpu.FilterMode_Nearest,
 		MipmapFilter:   wgpu.MipmapFilterMode_Nearest,
-		Compare:        wgpu.CompareFunction_LessEqual,
+		Compare:        wgpu.CompareFunction_GreaterEqual,
 		LodMinClamp:    0,
 		LodMaxClamp:    32,
 		MaxAnisotrophy: 1,